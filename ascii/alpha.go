@@ -0,0 +1,96 @@
+package ascii
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ParseBackground parses the color transparent regions are composited onto:
+// "transparent", "black", "white" or a hex color like "#1e1e2e" or "#fff".
+// Transparent yields nil, which keeps transparent regions blank.
+func ParseBackground(spec string) (color.Color, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	switch s {
+	case "", "transparent", "none":
+		return nil, nil
+	case "black":
+		return color.Black, nil
+	case "white":
+		return color.White, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return nil, fmt.Errorf("invalid background: %s", spec)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// resolveAlpha turns a premultiplied sample into the color a cell shows.
+// With a background the sample is composited over it and comes out opaque.
+// Without one the color is un-premultiplied, so semi-transparent edges keep
+// their hue instead of fading towards black, and the alpha is kept.
+func (cv *converter) resolveAlpha(c color.RGBA64) color.RGBA64 {
+	if c.A == 0xffff {
+		return c
+	}
+	if cv.bg != nil {
+		bg := cv.bg
+		inv := 0xffff - uint32(c.A)
+		over := func(v, b uint16) uint16 {
+			return clamp16(uint32(v) + (uint32(b)*inv+0x7fff)/0xffff)
+		}
+		return color.RGBA64{R: over(c.R, bg.R), G: over(c.G, bg.G), B: over(c.B, bg.B), A: 0xffff}
+	}
+	if c.A == 0 {
+		return color.RGBA64{}
+	}
+	a := uint32(c.A)
+	straight := func(v uint16) uint16 {
+		return clamp16((uint32(v)*0xffff + a/2) / a)
+	}
+	return color.RGBA64{R: straight(c.R), G: straight(c.G), B: straight(c.B), A: c.A}
+}
+
+// resolveSamples resolves the alpha of every sample in place.
+func (cv *converter) resolveSamples(samples []color.RGBA64) {
+	for i, c := range samples {
+		samples[i] = cv.resolveAlpha(c)
+	}
+}
+
+// sampleGray is getGrayValue for a resolved sample. Transparent samples are
+// the blank end of the ramp, so they draw nothing and dither without error.
+func (cv *converter) sampleGray(c color.RGBA64) uint8 {
+	if transparent(c) {
+		return 255
+	}
+	return cv.getGrayValue(c)
+}
+
+// transparent reports whether a resolved sample has nothing to show.
+func transparent(c color.RGBA64) bool {
+	return c.A>>8 == 0
+}
+
+// cellColor converts a resolved sample into a cell color. A terminal can't
+// show partial transparency, so anything visible is opaque.
+func cellColor(c color.RGBA64) color.RGBA {
+	if transparent(c) {
+		return color.RGBA{}
+	}
+	return color.RGBA{R: uint8(c.R >> 8), G: uint8(c.G >> 8), B: uint8(c.B >> 8), A: 0xff}
+}
+
+func clamp16(v uint32) uint16 {
+	if v > 0xffff {
+		return 0xffff
+	}
+	return uint16(v)
+}
@@ -0,0 +1,101 @@
+package ascii
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseBackground(t *testing.T) {
+	tests := []struct {
+		spec string
+		want color.Color // nil for none
+		ok   bool
+	}{
+		{"", nil, true},
+		{"transparent", nil, true},
+		{" None ", nil, true},
+		{"black", color.Black, true},
+		{"WHITE", color.White, true},
+		{"#fff", color.RGBA{255, 255, 255, 255}, true},
+		{"#abc", color.RGBA{0xaa, 0xbb, 0xcc, 255}, true},
+		{"#1e1e2e", color.RGBA{0x1e, 0x1e, 0x2e, 255}, true},
+		{"1E1E2E", color.RGBA{0x1e, 0x1e, 0x2e, 255}, true},
+		{"red", nil, false},
+		{"#", nil, false},
+		{"#ff", nil, false},
+		{"#ffff", nil, false},
+		{"#1234567", nil, false},
+		{"#12345g", nil, false},
+		{"#+12345", nil, false},
+		{"#0x1234", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseBackground(tt.spec)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseBackground(%q): got error %v, want ok %v", tt.spec, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBackground(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAlpha(t *testing.T) {
+	halfRed := color.RGBA64{0x8000, 0, 0, 0x8000}
+	tests := []struct {
+		name       string
+		background color.Color
+		in, want   color.RGBA64
+	}{
+		{"opaque is kept", nil, color.RGBA64{1, 2, 3, 0xffff}, color.RGBA64{1, 2, 3, 0xffff}},
+		{"opaque over a background", color.White, color.RGBA64{1, 2, 3, 0xffff}, color.RGBA64{1, 2, 3, 0xffff}},
+		{"half red keeps its hue", nil, halfRed, color.RGBA64{0xffff, 0, 0, 0x8000}},
+		{"quarter gray", nil, color.RGBA64{0x2000, 0x2000, 0x2000, 0x4000}, color.RGBA64{0x8000, 0x8000, 0x8000, 0x4000}},
+		{"transparent stays blank", nil, color.RGBA64{5, 5, 5, 0}, color.RGBA64{}},
+		{"half red over black", color.Black, halfRed, color.RGBA64{0x8000, 0, 0, 0xffff}},
+		{"half red over white", color.White, halfRed, color.RGBA64{0xffff, 0x7fff, 0x7fff, 0xffff}},
+		{"transparent shows the background", color.RGBA{0x33, 0x66, 0x99, 0xff}, color.RGBA64{}, color.RGBA64{0x3333, 0x6666, 0x9999, 0xffff}},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Background = tt.background
+		cv, err := newConverter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cv.resolveAlpha(tt.in); got != tt.want {
+			t.Errorf("%s: resolveAlpha(%v) = %v, want %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertResolvesEdges(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 128})
+
+	tests := []struct {
+		name       string
+		background color.Color
+		want       color.RGBA
+	}{
+		{"without a background", nil, color.RGBA{255, 0, 0, 255}},
+		{"over white", color.White, color.RGBA{255, 127, 127, 255}},
+		{"over black", color.Black, color.RGBA{128, 0, 0, 255}},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Width = 1
+		opts.ColorMode = "truecolor"
+		opts.Background = tt.background
+		res, err := Convert(context.Background(), img, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Frames[0].Cells[0].Color; got != tt.want {
+			t.Errorf("%s: cell color %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
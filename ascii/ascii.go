@@ -0,0 +1,455 @@
+// Package ascii converts images and animated GIFs into character art.
+//
+// It has no global state and never writes to stdout: Convert and ConvertGIF
+// return rendered frames, and the encoders write them to any io.Writer in
+// text, ANSI, HTML, SVG or JSON form.
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"sort"
+	"sync/atomic"
+)
+
+// charsets holds the built-in character sets, darkest glyph first. It is
+// only read, callers get at it through Charset and CharsetNames.
+var charsets = map[string]string{
+	"default": "@%#*+=-:. ",
+	"blocks":  "█▉▊▋▌▍▎▏ ",
+	"dots":    "⣿⣾⣽⣻⢿⡿⣟⣯⣷⣶⣴⣤⣠⣀ ",
+	"classic": "$@B%8&WM#*oahkbdpqwmZO0QLCJUYXzcvunxrjft/\\|()1{}[]?-_+~<>i!lI;:,\"^`'. ",
+	"simple":  "##++--.. ",
+	"minimal": "█░ ",
+	"retro":   "▓▒░ ",
+	"sigma":   "σΣαβγδ ",
+	"ohio":    "OHIO ",
+	"rizz":    "RIZZ ",
+	"gyatt":   "GYATT ",
+	"skibidi": "SKIBIDI ",
+	"cringe":  "💀😭🔥💯 ",
+	"based":   "BASED ",
+	"sussy":   "ඞ๖♡◄►▲▼ ",
+}
+
+// Charset returns the glyphs of a built-in character set, darkest first.
+func Charset(name string) (string, bool) {
+	set, ok := charsets[name]
+	return set, ok
+}
+
+// CharsetNames returns the names of the built-in character sets, sorted.
+func CharsetNames() []string {
+	names := make([]string, 0, len(charsets))
+	for name := range charsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options controls a conversion. Start from DefaultOptions, the zero value
+// has a contrast of 0 and renders a blank image.
+type Options struct {
+	Width      int             // output width in characters, 0 for 80
+	Height     int             // output height in characters, 0 for automatic
+	ScaleMode  string          // maintain, fit or stretch
+	Charset    string          // one of CharsetNames()
+	Invert     bool            // invert brightness
+	Threshold  int             // binarize at this gray value, 0 to disable
+	Contrast   float64         // contrast multiplier, 1 leaves the image as is
+	Brightness float64         // added to every channel after contrast
+	Quality    string          // resampling: fast, normal, high or a filter name
+	Dither     string          // one of DitherModes()
+	Luma       string          // one of LumaModels(), how colors become brightness
+	Tone       string          // tone mapping before glyph selection, see ParseTone
+	RenderMode string          // one of RenderModes()
+	ColorMode  string          // none, 16, 256 or truecolor
+	Crop       image.Rectangle // region of interest relative to the image origin, empty for all
+	Jobs       int             // worker goroutines, 0 for runtime.NumCPU()
+	Seed       int64           // pattern of the noise dither, same seed same output
+	Background color.Color     // composited under transparent regions, nil leaves them blank
+
+	// Progress, when set, is called as rows are rendered, or as frames are
+	// delivered for animations. Calls never overlap, but they may come from
+	// any worker goroutine.
+	Progress func(done, total int)
+}
+
+// DefaultOptions returns the options the CLI uses when no flags are given.
+func DefaultOptions() Options {
+	return Options{
+		Width:      80,
+		ScaleMode:  "maintain",
+		Charset:    "default",
+		Contrast:   1.0,
+		Quality:    "normal",
+		Dither:     "none",
+		Luma:       "rec601",
+		Tone:       "none",
+		RenderMode: "ascii",
+		ColorMode:  "none",
+	}
+}
+
+// Result is the outcome of a conversion.
+type Result struct {
+	Frames     []*Frame
+	Width      int // width of the frames in characters
+	Height     int // height of the frames in characters
+	Charset    string
+	ColorDepth ColorDepth
+	PixelCount int64 // source samples that went into the output
+
+	Brightness float64    // average luminance of the cell colors, 0-255
+	Dominant   color.RGBA // most common cell color, in 4-bit-per-channel boxes
+}
+
+// converter holds everything derived from Options once per conversion.
+type converter struct {
+	opts    Options
+	charset *charset
+	depth   ColorDepth
+	filter  *resampleFilter
+	luma    lumaTable
+	tone    Tone
+	bg      *color.RGBA64 // background under transparent regions, nil for none
+	onRow   func(done, total int)
+	rowJobs int          // row workers per frame, 0 for all of them
+	pixels  atomic.Int64 // frames may render concurrently
+	stats   colorStats
+}
+
+func newConverter(opts Options) (*converter, error) {
+	if _, ok := charsets[opts.Charset]; !ok {
+		return nil, fmt.Errorf("invalid ASCII set: %s", opts.Charset)
+	}
+	if opts.Width < 0 || opts.Height < 0 {
+		return nil, fmt.Errorf("invalid output size %dx%d", opts.Width, opts.Height)
+	}
+
+	depth, err := ParseColorDepth(opts.ColorMode)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := filterForQuality(opts.Quality)
+	if err != nil {
+		return nil, err
+	}
+	dither, err := NormalizeDitherMode(opts.Dither)
+	if err != nil {
+		return nil, err
+	}
+	opts.Dither = dither
+	luma, err := NormalizeLumaModel(opts.Luma)
+	if err != nil {
+		return nil, err
+	}
+	opts.Luma = luma
+	tone, err := ParseTone(opts.Tone)
+	if err != nil {
+		return nil, err
+	}
+
+	validMode := false
+	for _, mode := range renderModes {
+		if opts.RenderMode == mode {
+			validMode = true
+			break
+		}
+	}
+	if !validMode {
+		return nil, fmt.Errorf("invalid render mode: %s", opts.RenderMode)
+	}
+
+	cv := &converter{
+		opts:    opts,
+		charset: newCharset(opts.Charset),
+		depth:   depth,
+		filter:  filter,
+		luma:    newLumaTable(luma, opts.Contrast, opts.Brightness, opts.Invert),
+		tone:    tone,
+		onRow:   opts.Progress,
+	}
+	if opts.Background != nil {
+		bg := color.RGBA64Model.Convert(opts.Background).(color.RGBA64)
+		bg.A = 0xffff
+		cv.bg = &bg
+	}
+	return cv, nil
+}
+
+// Convert renders a single image.
+func Convert(ctx context.Context, img image.Image, opts Options) (*Result, error) {
+	cv, err := newConverter(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCrop(opts.Crop, img.Bounds()); err != nil {
+		return nil, err
+	}
+
+	frame, err := cv.render(ctx, img)
+	if err != nil {
+		return nil, err
+	}
+	return cv.result([]*Frame{frame}), nil
+}
+
+// ConvertGIF renders every frame of an animated GIF, composited onto the full
+// logical screen, with the frame delays filled in.
+func ConvertGIF(ctx context.Context, g *gif.GIF, opts Options) (*Result, error) {
+	frames := make([]*Frame, 0, len(g.Image))
+	res, err := StreamGIF(ctx, g, opts, func(frame *Frame) error {
+		frames = append(frames, frame)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Frames = frames
+	return res, nil
+}
+
+// StreamGIF is ConvertGIF for playback and long outputs: frames are rendered
+// concurrently and handed to fn one at a time in display order as soon as
+// they are ready, and only a few frames are held in memory at once. The
+// returned Result has no Frames. An error from fn stops the conversion.
+func StreamGIF(ctx context.Context, g *gif.GIF, opts Options, fn func(frame *Frame) error) (*Result, error) {
+	cv, err := newConverter(opts)
+	if err != nil {
+		return nil, err
+	}
+	compositor := NewCompositor(g)
+	if err := checkCrop(opts.Crop, compositor.Bounds()); err != nil {
+		return nil, err
+	}
+
+	// Frames render side by side, so per-row progress would interleave
+	cv.onRow = nil
+	var width, height int
+	err = cv.streamGIF(ctx, g, compositor, func(i int, frame *Frame) error {
+		width, height = frame.Width, frame.Height
+		if err := fn(frame); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(g.Image))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := cv.result(nil)
+	res.Width, res.Height = width, height
+	return res, nil
+}
+
+func (cv *converter) result(frames []*Frame) *Result {
+	res := &Result{
+		Frames:     frames,
+		Charset:    cv.charset.name,
+		ColorDepth: cv.depth,
+		PixelCount: cv.pixels.Load(),
+		Brightness: cv.stats.brightness(),
+		Dominant:   cv.stats.dominant(),
+	}
+	if len(frames) > 0 {
+		res.Width, res.Height = frames[0].Width, frames[0].Height
+	}
+	return res
+}
+
+func (cv *converter) getGrayValue(c color.RGBA64) uint8 {
+	// Luminance, contrast, brightness and invert are precomputed per 8-bit
+	// channel value
+	if cv.luma.linear {
+		return cv.luma.linearGray(c)
+	}
+	return cv.luma.gammaGray(c)
+}
+
+func clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func (cv *converter) grayToASCII(gray uint8) string {
+	// Apply threshold if set
+	if cv.opts.Threshold > 0 {
+		if int(gray) < cv.opts.Threshold {
+			gray = 0
+		} else {
+			gray = 255
+		}
+	}
+
+	// Map gray value to a whole glyph of the decoded set
+	return cv.charset.glyph(gray)
+}
+
+// maxCells caps the output grid, so a tall sliver of an image or a huge
+// --width can't ask for a frame larger than memory. 1000x1000 fits.
+const maxCells = 1 << 20
+
+// outputSize calculates the output grid in characters for a source image.
+func (cv *converter) outputSize(width, height int) (int, int, error) {
+	var newWidth, newHeight int
+
+	// Calculate output dimensions based on scale mode
+	switch cv.opts.ScaleMode {
+	case "fit":
+		if cv.opts.Width > 0 && cv.opts.Height > 0 {
+			newWidth, newHeight = cv.opts.Width, cv.opts.Height
+		} else if cv.opts.Width > 0 {
+			newWidth = cv.opts.Width
+			aspectRatio := float64(height) / float64(width)
+			newHeight = int(float64(newWidth) * aspectRatio * 0.43) // Adjust for character aspect ratio
+		} else {
+			newWidth = 80
+			aspectRatio := float64(height) / float64(width)
+			newHeight = int(float64(newWidth) * aspectRatio * 0.43)
+		}
+	case "stretch":
+		newWidth = cv.opts.Width
+		newHeight = cv.opts.Height
+		if newWidth == 0 {
+			newWidth = 80
+		}
+		if newHeight == 0 {
+			newHeight = 40
+		}
+	default: // maintain
+		newWidth = cv.opts.Width
+		if newWidth == 0 {
+			newWidth = 80
+		}
+		aspectRatio := float64(height) / float64(width)
+		newHeight = int(float64(newWidth) * aspectRatio * 0.43)
+	}
+
+	if newHeight < 1 {
+		newHeight = 1
+	}
+	if newWidth > maxCells/newHeight {
+		return 0, 0, fmt.Errorf("output too large (%dx%d characters), at most %d cells", newWidth, newHeight, maxCells)
+	}
+	return newWidth, newHeight, nil
+}
+
+// render converts one image into a frame in the configured render mode.
+func (cv *converter) render(ctx context.Context, img image.Image) (*Frame, error) {
+	if !cv.opts.Crop.Empty() {
+		img = cropImage(img, cv.opts.Crop)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	newWidth, newHeight, err := cv.outputSize(width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	var frame *Frame
+	switch cv.opts.RenderMode {
+	case "braille":
+		frame, err = cv.renderBraille(ctx, img, newWidth, newHeight)
+	case "half", "quadrant", "sextant":
+		frame, err = cv.renderBlocks(ctx, img, newWidth, newHeight)
+	default:
+		frame, err = cv.renderASCII(ctx, img, newWidth, newHeight)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cv.stats.observe(frame)
+	return frame, nil
+}
+
+// renderASCII maps every cell onto one glyph of the character set.
+func (cv *converter) renderASCII(ctx context.Context, img image.Image, newWidth, newHeight int) (*Frame, error) {
+	// Each cell gets the filtered color of the source area it covers
+	samples, err := cv.resample(ctx, img, newWidth, newHeight)
+	if err != nil {
+		return nil, err
+	}
+	cv.resolveSamples(samples)
+
+	frame := newFrame(newWidth, newHeight)
+	grays := make([]uint8, newWidth*newHeight)
+
+	progress := cv.newRowProgress(newHeight)
+	err = cv.parallelRows(ctx, newHeight, func(start, end int) {
+		for i := start * newWidth; i < end*newWidth; i++ {
+			pixel := samples[i]
+			grays[i] = cv.sampleGray(pixel)
+			frame.Cells[i].Color = cellColor(pixel)
+		}
+		progress.add(end - start)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cv.applyTone(grays, samples, newWidth, newHeight)
+
+	// Glyph selection, through the dithering stage when one is configured
+	if cv.opts.Dither == "none" {
+		for i, gray := range grays {
+			frame.Cells[i].Glyph = cv.grayToASCII(gray)
+		}
+	} else {
+		q := quantizer{levels: len(cv.charset.glyphs), threshold: cv.opts.Threshold}
+		for i, level := range ditherGrid(cv.opts.Dither, grays, newWidth, newHeight, q, cv.opts.Seed) {
+			frame.Cells[i].Glyph = cv.charset.glyphs[level]
+		}
+	}
+
+	// Transparent cells stay blank whatever the threshold or dither made of them
+	for i := range frame.Cells {
+		if frame.Cells[i].Color.A == 0 {
+			frame.Cells[i].Glyph = " "
+		}
+	}
+
+	cv.pixels.Add(int64(newWidth * newHeight))
+	return frame, nil
+}
+
+// Encode writes the whole result in the given format.
+func (r *Result) Encode(w io.Writer, format string) error {
+	enc, err := NewEncoder(format, w)
+	if err != nil {
+		return err
+	}
+
+	info := OutputInfo{
+		Title:      r.Charset,
+		Charset:    r.Charset,
+		ColorDepth: r.ColorDepth,
+		Animated:   len(r.Frames) > 1,
+	}
+	if err := enc.Begin(info); err != nil {
+		return err
+	}
+	for _, frame := range r.Frames {
+		if err := enc.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+	return enc.End()
+}
@@ -0,0 +1,128 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewConverterValidatesOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		adjust func(*Options)
+		ok     bool
+	}{
+		{"defaults", func(o *Options) {}, true},
+		{"automatic size", func(o *Options) { o.Width, o.Height = 0, 0 }, true},
+		{"negative width", func(o *Options) { o.Width = -5 }, false},
+		{"negative height", func(o *Options) { o.Height = -1 }, false},
+		{"unknown charset", func(o *Options) { o.Charset = "nope" }, false},
+		{"unknown render mode", func(o *Options) { o.RenderMode = "pixels" }, false},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		tt.adjust(&opts)
+		if _, err := newConverter(opts); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestModeLists(t *testing.T) {
+	lists := []struct {
+		name   string
+		list   func() []string
+		accept func(string) error
+	}{
+		{"RenderModes", RenderModes, func(m string) error {
+			opts := DefaultOptions()
+			opts.RenderMode = m
+			_, err := newConverter(opts)
+			return err
+		}},
+		{"Formats", Formats, func(f string) error {
+			_, err := NewEncoder(f, io.Discard)
+			return err
+		}},
+		{"DitherModes", DitherModes, func(m string) error {
+			if got, err := NormalizeDitherMode(m); err != nil || got != m {
+				return fmt.Errorf("resolves to %q, %v", got, err)
+			}
+			return nil
+		}},
+		{"QualityLevels", QualityLevels, func(q string) error {
+			_, err := filterForQuality(q)
+			return err
+		}},
+		{"LumaModels", LumaModels, func(m string) error {
+			if got, err := NormalizeLumaModel(m); err != nil || got != m {
+				return fmt.Errorf("resolves to %q, %v", got, err)
+			}
+			return nil
+		}},
+		{"ToneModes", ToneModes, func(m string) error {
+			_, err := ParseTone(m)
+			return err
+		}},
+	}
+	for _, l := range lists {
+		names := l.list()
+		if len(names) == 0 {
+			t.Errorf("%s() is empty", l.name)
+			continue
+		}
+		for _, name := range names {
+			if err := l.accept(name); err != nil {
+				t.Errorf("%s() lists %q, which isn't accepted: %v", l.name, name, err)
+			}
+		}
+		names[0] = "changed"
+		if l.list()[0] == "changed" {
+			t.Errorf("%s shares its slice with callers", l.name)
+		}
+	}
+}
+
+func TestOutputSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		adjust        func(*Options)
+		width, height int
+		wantW, wantH  int
+		ok            bool
+	}{
+		{"maintain", func(o *Options) {}, 200, 100, 80, 17, true},
+		{"maintain keeps a row", func(o *Options) {}, 1000, 1, 80, 1, true},
+		{"fit with both sides", func(o *Options) { o.ScaleMode, o.Width, o.Height = "fit", 30, 10 }, 200, 100, 30, 10, true},
+		{"stretch defaults", func(o *Options) { o.ScaleMode, o.Width, o.Height = "stretch", 0, 0 }, 200, 100, 80, 40, true},
+		{"at the cap", func(o *Options) { o.ScaleMode, o.Width, o.Height = "stretch", 1024, 1024 }, 1, 1, 1024, 1024, true},
+		{"over the cap", func(o *Options) { o.ScaleMode, o.Width, o.Height = "stretch", 1025, 1024 }, 1, 1, 0, 0, false},
+		{"tall sliver", func(o *Options) {}, 1, 100000, 0, 0, false},
+		{"huge width", func(o *Options) { o.Width = 1 << 40 }, 100, 100, 0, 0, false},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		tt.adjust(&opts)
+		cv, err := newConverter(opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		w, h, err := cv.outputSize(tt.width, tt.height)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && (w != tt.wantW || h != tt.wantH) {
+			t.Errorf("%s: outputSize(%d, %d) = %dx%d, want %dx%d", tt.name, tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+	}
+
+	// The cap is checked before anything is allocated for the frame
+	img := image.NewGray(image.Rect(0, 0, 1, 100000))
+	if _, err := Convert(context.Background(), img, DefaultOptions()); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("converting a 1x100000 image: %v, want a too large error", err)
+	}
+}
@@ -0,0 +1,288 @@
+package ascii
+
+import (
+	"context"
+	"image"
+	"image/color"
+)
+
+// renderModes are the ways a cell can be drawn: one glyph by brightness, or
+// a grid of sub-pixels in block or braille characters.
+var renderModes = []string{"ascii", "braille", "half", "quadrant", "sextant"}
+
+// RenderModes returns the render modes newConverter accepts.
+func RenderModes() []string {
+	return append([]string(nil), renderModes...)
+}
+
+// blockLayout describes a block render mode: how many sub-pixels a cell
+// covers and which glyph draws a given mask of them in the foreground color.
+// Mask bits are numbered row-major from the top left sub-pixel.
+type blockLayout struct {
+	cols, rows int
+	glyph      func(mask int) string
+}
+
+var blockLayouts = map[string]*blockLayout{
+	"half": {
+		cols: 1, rows: 2,
+		glyph: func(mask int) string {
+			return []string{" ", "▀", "▄", "█"}[mask]
+		},
+	},
+	"quadrant": {
+		cols: 2, rows: 2,
+		glyph: func(mask int) string {
+			return quadrantGlyphs[mask]
+		},
+	},
+	"sextant": {
+		cols: 2, rows: 3,
+		glyph: sextantGlyph,
+	},
+}
+
+var quadrantGlyphs = [16]string{
+	" ", "▘", "▝", "▀", "▖", "▌", "▞", "▛",
+	"▗", "▚", "▐", "▜", "▄", "▙", "▟", "█",
+}
+
+// sextantGlyph maps a 2x3 mask onto the Unicode 13 sextant block. The range
+// skips the patterns that already exist as half blocks (left and right column).
+func sextantGlyph(mask int) string {
+	switch mask {
+	case 0:
+		return " "
+	case 21:
+		return "▌"
+	case 42:
+		return "▐"
+	case 63:
+		return "█"
+	}
+	code := 0x1fb00 + mask - 1
+	if mask > 21 {
+		code--
+	}
+	if mask > 42 {
+		code--
+	}
+	return string(rune(code))
+}
+
+// renderBlocks renders half block, quadrant or sextant cells. With color on,
+// every cell gets the split of its sub-pixels into a foreground and background
+// group that best approximates them, after the colors are relit to the tone
+// adjustments. Without color the dark sub-pixels are drawn, thresholded or
+// dithered like the braille dots.
+func (cv *converter) renderBlocks(ctx context.Context, img image.Image, cols, rows int) (*Frame, error) {
+	layout := blockLayouts[cv.opts.RenderMode]
+	subW, subH := cols*layout.cols, rows*layout.rows
+	samples, err := cv.resample(ctx, img, subW, subH)
+	if err != nil {
+		return nil, err
+	}
+	cv.resolveSamples(samples)
+
+	var dots []int
+	if cv.depth == ColorNone {
+		grays := make([]uint8, len(samples))
+		for i, pixel := range samples {
+			grays[i] = cv.sampleGray(pixel)
+		}
+		cv.applyTone(grays, samples, subW, subH)
+		dots = ditherGrid(cv.opts.Dither, grays, subW, subH, quantizer{levels: 2, threshold: cv.opts.Threshold}, cv.opts.Seed)
+	} else if cv.adjustsTone() {
+		cv.relight(samples, subW, subH)
+	}
+
+	frame := newFrame(cols, rows)
+	progress := cv.newRowProgress(rows)
+	err = cv.parallelRows(ctx, rows, func(start, end int) {
+		n := layout.cols * layout.rows
+		pixels := make([]color.RGBA, n)
+		levels := make([]int, n)
+		for y := start; y < end; y++ {
+			for x := 0; x < cols; x++ {
+				for sy := 0; sy < layout.rows; sy++ {
+					for sx := 0; sx < layout.cols; sx++ {
+						i := (y*layout.rows+sy)*subW + x*layout.cols + sx
+						pixels[sy*layout.cols+sx] = cellColor(samples[i])
+						if dots != nil {
+							levels[sy*layout.cols+sx] = dots[i]
+						}
+					}
+				}
+				frame.Cells[y*cols+x] = blockCell(layout, pixels, levels, dots != nil)
+			}
+		}
+		progress.add(end - start)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cv.pixels.Add(int64(subW * subH))
+	return frame, nil
+}
+
+// adjustsTone reports whether any of contrast, brightness, invert or a tone
+// mapping would change the gray values.
+func (cv *converter) adjustsTone() bool {
+	return cv.opts.Contrast != 1 || cv.opts.Brightness != 0 || cv.opts.Invert || cv.tone.Mode != "none"
+}
+
+// relight applies the gray value adjustments to colored sub-pixels. Invert
+// turns every color into its negative, then each color is darkened toward
+// black or lightened toward white until its luma, measured without
+// adjustments, matches the adjusted and tone mapped gray value. The linear
+// models do this in linear light, where luminance scales with the channels.
+// The hue is kept, so colored blocks follow the same tones as the glyph modes.
+func (cv *converter) relight(samples []color.RGBA64, w, h int) {
+	grays := make([]uint8, len(samples))
+	for i, c := range samples {
+		grays[i] = cv.sampleGray(c)
+	}
+	cv.applyTone(grays, samples, w, h)
+
+	plain := newLumaTable(cv.opts.Luma, 1, 0, false)
+	plainGray, linear := plain.gammaGray, plain.linear
+	if linear {
+		plainGray = plain.linearGray
+	}
+	weights := lumaWeights[cv.opts.Luma]
+	for i, c := range samples {
+		if transparent(c) {
+			continue
+		}
+		if cv.opts.Invert {
+			c.R, c.G, c.B = 0xffff-c.R, 0xffff-c.G, 0xffff-c.B
+		}
+		samples[i] = c
+
+		// Colors already at the right gray level are kept exactly
+		if plainGray(c) == grays[i] {
+			continue
+		}
+
+		rgb := [3]float64{float64(c.R) / 0xffff, float64(c.G) / 0xffff, float64(c.B) / 0xffff}
+		to := float64(grays[i]) / 255
+		if linear {
+			for k := range rgb {
+				rgb[k] = srgbToLinear(rgb[k])
+			}
+			to = grayLuminance(cv.opts.Luma, to)
+		}
+		from := weights[0]*rgb[0] + weights[1]*rgb[1] + weights[2]*rgb[2]
+
+		for k, v := range rgb {
+			if to < from {
+				v *= to / from
+			} else if to > from {
+				v += (1 - v) * (to - from) / (1 - from)
+			}
+			if linear {
+				v = linearToSRGB(v)
+			}
+			rgb[k] = clamp(v*0xffff+0.5, 0, 0xffff)
+		}
+		samples[i].R, samples[i].G, samples[i].B = uint16(rgb[0]), uint16(rgb[1]), uint16(rgb[2])
+	}
+}
+
+// blockCell builds one cell from its sub-pixels. Monochrome cells draw the
+// sub-pixels whose level is 0, color cells use the best fg/bg split. Cells
+// with transparent sub-pixels draw the visible ones and have no background.
+func blockCell(layout *blockLayout, pixels []color.RGBA, levels []int, mono bool) Cell {
+	visible := 0
+	for i, p := range pixels {
+		if p.A != 0 {
+			visible |= 1 << i
+		}
+	}
+
+	if mono {
+		mask := 0
+		for i, level := range levels {
+			if level == 0 {
+				mask |= 1 << i
+			}
+		}
+		mask &= visible
+		return Cell{
+			Glyph: layout.glyph(mask),
+			Color: averageColor(pixels, mask, true),
+		}
+	}
+
+	if visible != 1<<len(pixels)-1 {
+		return Cell{
+			Glyph: layout.glyph(visible),
+			Color: averageColor(pixels, visible, true),
+		}
+	}
+
+	mask, fg, bg := bestBlockSplit(pixels)
+	return Cell{
+		Glyph:         layout.glyph(mask),
+		Color:         fg,
+		Background:    bg,
+		HasBackground: true,
+	}
+}
+
+// bestBlockSplit tries every way to split the sub-pixels into two groups and
+// returns the mask with the lowest squared error, along with the average color
+// of the masked (foreground) and unmasked (background) groups. A mask and its
+// complement draw the same picture, so only masks containing bit 0 are tried.
+func bestBlockSplit(pixels []color.RGBA) (int, color.RGBA, color.RGBA) {
+	full := 1<<len(pixels) - 1
+	bestMask, bestErr := full, -1
+	var bestFG, bestBG color.RGBA
+
+	for mask := 1; mask <= full; mask += 2 {
+		fg := averageColor(pixels, mask, true)
+		bg := averageColor(pixels, mask, false)
+
+		errSum := 0
+		for i, p := range pixels {
+			target := bg
+			if mask&(1<<i) != 0 {
+				target = fg
+			}
+			errSum += colorDistance(p.R, p.G, p.B, target.R, target.G, target.B)
+		}
+
+		// The full mask is tried last and wins ties, so uniform cells draw as
+		// a full block rather than an arbitrary split of equal colors
+		if bestErr < 0 || errSum < bestErr || (errSum == bestErr && mask == full) {
+			bestMask, bestErr = mask, errSum
+			bestFG, bestBG = fg, bg
+		}
+	}
+
+	if bestMask == full {
+		// Uniform cell, keep the background in the same color
+		bestBG = bestFG
+	}
+	return bestMask, bestFG, bestBG
+}
+
+// averageColor averages the pixels selected (or not selected) by mask.
+func averageColor(pixels []color.RGBA, mask int, selected bool) color.RGBA {
+	var r, g, b, a, count int
+	for i, p := range pixels {
+		if (mask&(1<<i) != 0) != selected {
+			continue
+		}
+		r += int(p.R)
+		g += int(p.G)
+		b += int(p.B)
+		a += int(p.A)
+		count++
+	}
+	if count == 0 {
+		return color.RGBA{}
+	}
+	return color.RGBA{uint8(r / count), uint8(g / count), uint8(b / count), uint8(a / count)}
+}
@@ -0,0 +1,214 @@
+package ascii
+
+import (
+	"image/color"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSextantGlyphCoversAllPatterns(t *testing.T) {
+	seen := make(map[string]int)
+	for mask := 0; mask < 64; mask++ {
+		glyph := sextantGlyph(mask)
+		if utf8.RuneCountInString(glyph) != 1 {
+			t.Fatalf("mask %d: %q is not a single character", mask, glyph)
+		}
+		if prev, dup := seen[glyph]; dup {
+			t.Errorf("masks %d and %d both map to %q", prev, mask, glyph)
+		}
+		seen[glyph] = mask
+
+		r, _ := utf8.DecodeRuneInString(glyph)
+		switch mask {
+		case 0, 21, 42, 63:
+			continue
+		}
+		if r < 0x1fb00 || r > 0x1fb3b {
+			t.Errorf("mask %d: U+%04X is outside the sextant block", mask, r)
+		}
+	}
+}
+
+// Code points from the Unicode chart, named after the sextants they fill:
+// 1 2 on the top row, 3 4 in the middle, 5 6 at the bottom.
+func TestSextantGlyphCodePoints(t *testing.T) {
+	tests := []struct {
+		name string
+		mask int
+		want rune
+	}{
+		{"empty", 0, ' '},
+		{"SEXTANT-1", 0b000001, 0x1fb00},
+		{"SEXTANT-2", 0b000010, 0x1fb01},
+		{"SEXTANT-12", 0b000011, 0x1fb02},
+		{"SEXTANT-1234", 0b001111, 0x1fb0e},
+		{"SEXTANT-5", 0b010000, 0x1fb0f},
+		{"SEXTANT-35", 0b010100, 0x1fb13},
+		{"left half", 0b010101, '▌'},
+		{"SEXTANT-235", 0b010110, 0x1fb14},
+		{"SEXTANT-146", 0b101001, 0x1fb27},
+		{"right half", 0b101010, '▐'},
+		{"SEXTANT-1246", 0b101011, 0x1fb28},
+		{"SEXTANT-23456", 0b111110, 0x1fb3b},
+		{"full", 0b111111, '█'},
+	}
+	for _, tt := range tests {
+		if got, _ := utf8.DecodeRuneInString(sextantGlyph(tt.mask)); got != tt.want {
+			t.Errorf("%s (mask %d) = U+%04X, want U+%04X", tt.name, tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestQuadrantAndHalfGlyphs(t *testing.T) {
+	half := blockLayouts["half"]
+	for mask, want := range []string{" ", "▀", "▄", "█"} {
+		if got := half.glyph(mask); got != want {
+			t.Errorf("half mask %d = %q, want %q", mask, got, want)
+		}
+	}
+
+	// Every quadrant glyph must be distinct, and the single quadrants sit
+	// where the mask bits say
+	quadrant := blockLayouts["quadrant"]
+	seen := make(map[string]bool)
+	for mask := 0; mask < 16; mask++ {
+		glyph := quadrant.glyph(mask)
+		if seen[glyph] {
+			t.Errorf("quadrant glyph %q used twice", glyph)
+		}
+		seen[glyph] = true
+	}
+	for mask, want := range map[int]string{1: "▘", 2: "▝", 4: "▖", 8: "▗", 3: "▀", 5: "▌", 9: "▚"} {
+		if got := quadrant.glyph(mask); got != want {
+			t.Errorf("quadrant mask %d = %q, want %q", mask, got, want)
+		}
+	}
+}
+
+func TestBestBlockSplit(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	gray := color.RGBA{100, 100, 100, 255}
+
+	tests := []struct {
+		name   string
+		pixels []color.RGBA
+		mask   int
+		fg, bg color.RGBA
+	}{
+		{"uniform", []color.RGBA{gray, gray, gray, gray}, 0b1111, gray, gray},
+		{"top and bottom", []color.RGBA{red, red, blue, blue}, 0b0011, red, blue},
+		{"checkerboard", []color.RGBA{red, blue, blue, red}, 0b1001, red, blue},
+		{"one corner", []color.RGBA{blue, red, red, red}, 0b0001, blue, red},
+		{"sextant column", []color.RGBA{red, blue, red, blue, red, blue}, 0b010101, red, blue},
+	}
+	for _, tt := range tests {
+		mask, fg, bg := bestBlockSplit(tt.pixels)
+		if mask != tt.mask || fg != tt.fg || bg != tt.bg {
+			t.Errorf("%s: got mask %b fg %v bg %v, want mask %b fg %v bg %v", tt.name, mask, fg, bg, tt.mask, tt.fg, tt.bg)
+		}
+	}
+}
+
+func TestBestBlockSplitAverages(t *testing.T) {
+	// Three close reds against one blue: the reds are grouped and averaged
+	pixels := []color.RGBA{
+		{250, 0, 0, 255}, {0, 0, 255, 255},
+		{240, 10, 0, 255}, {230, 20, 0, 255},
+	}
+	mask, fg, bg := bestBlockSplit(pixels)
+	if mask != 0b1101 {
+		t.Fatalf("mask %b, want 1101", mask)
+	}
+	if want := (color.RGBA{240, 10, 0, 255}); fg != want {
+		t.Errorf("fg %v, want %v", fg, want)
+	}
+	if want := (color.RGBA{0, 0, 255, 255}); bg != want {
+		t.Errorf("bg %v, want %v", bg, want)
+	}
+}
+
+func TestBlockCellTransparent(t *testing.T) {
+	layout := blockLayouts["half"]
+	red := color.RGBA{255, 0, 0, 255}
+	levels := make([]int, 2)
+
+	cell := blockCell(layout, []color.RGBA{red, {}}, levels, false)
+	if cell.Glyph != "▀" || cell.HasBackground || cell.Color != red {
+		t.Errorf("half transparent cell = %+v, want red upper half without background", cell)
+	}
+	cell = blockCell(layout, []color.RGBA{{}, {}}, levels, false)
+	if cell.Glyph != " " || cell.HasBackground {
+		t.Errorf("transparent cell = %+v, want a blank", cell)
+	}
+}
+
+func TestRelightMatchesAdjustedGray(t *testing.T) {
+	samples := []color.RGBA64{
+		{0xffff, 0, 0, 0xffff}, {0, 0x8000, 0, 0xffff}, {0x2000, 0x4000, 0xc000, 0xffff},
+		{0x6464, 0x6464, 0x6464, 0xffff}, {0, 0, 0, 0xffff}, {0xffff, 0xffff, 0xffff, 0xffff},
+	}
+	for _, luma := range lumaModels {
+		for _, adjust := range []func(*Options){
+			func(o *Options) { o.Contrast = 1.6 },
+			func(o *Options) { o.Brightness = -50 },
+			func(o *Options) { o.Brightness = 40; o.Invert = true },
+		} {
+			opts := DefaultOptions()
+			opts.Luma = luma
+			adjust(&opts)
+			cv, err := newConverter(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain := newLumaTable(luma, 1, 0, false)
+
+			lit := append([]color.RGBA64(nil), samples...)
+			cv.relight(lit, len(lit), 1)
+			for i, c := range lit {
+				want := int(cv.getGrayValue(samples[i]))
+				var got int
+				if plain.linear {
+					got = int(plain.linearGray(c))
+				} else {
+					got = int(plain.gammaGray(c))
+				}
+				if got < want-2 || got > want+2 {
+					t.Errorf("%s %+v: sample %d relit to luma %d, want %d", luma, opts, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRelightInvertIsNegative(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Invert = true
+	cv, err := newConverter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := []color.RGBA64{{0xffff, 0, 0, 0xffff}, {}}
+	cv.relight(samples, 2, 1)
+	if want := (color.RGBA64{0, 0xffff, 0xffff, 0xffff}); samples[0] != want {
+		t.Errorf("inverted red = %v, want %v", samples[0], want)
+	}
+	if samples[1] != (color.RGBA64{}) {
+		t.Errorf("transparent sample changed to %v", samples[1])
+	}
+}
+
+func TestAdjustsTone(t *testing.T) {
+	cv, err := newConverter(DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.adjustsTone() {
+		t.Error("default options should leave colored blocks alone")
+	}
+	opts := DefaultOptions()
+	opts.Tone = "equalize"
+	if cv, _ = newConverter(opts); !cv.adjustsTone() {
+		t.Error("a tone mapping should relight colored blocks")
+	}
+}
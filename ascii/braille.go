@@ -0,0 +1,107 @@
+package ascii
+
+import (
+	"context"
+	"image"
+	"image/color"
+)
+
+// brailleBase is the empty braille pattern, dots are added as bits on top.
+const brailleBase = 0x2800
+
+// brailleDots holds the bit of each dot in a braille cell, indexed [row][col].
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// renderBraille maps every output cell onto a 2x4 block of samples and sets
+// one braille dot per dark sample, giving 8x the resolution of a glyph ramp.
+func (cv *converter) renderBraille(ctx context.Context, img image.Image, cols, rows int) (*Frame, error) {
+	dotW, dotH := cols*2, rows*4
+	samples, err := cv.resample(ctx, img, dotW, dotH)
+	if err != nil {
+		return nil, err
+	}
+	cv.resolveSamples(samples)
+
+	grays := make([]uint8, len(samples))
+	for i, pixel := range samples {
+		grays[i] = cv.sampleGray(pixel)
+	}
+	cv.applyTone(grays, samples, dotW, dotH)
+
+	// Each dot is binary, so dithering happens at dot resolution
+	q := quantizer{levels: 2, threshold: cv.opts.Threshold}
+	dots := ditherGrid(cv.opts.Dither, grays, dotW, dotH, q, cv.opts.Seed)
+
+	frame := newFrame(cols, rows)
+	progress := cv.newRowProgress(rows)
+	err = cv.parallelRows(ctx, rows, func(start, end int) {
+		for y := start; y < end; y++ {
+			brailleRow(frame, samples, dots, y)
+		}
+		progress.add(end - start)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cv.pixels.Add(int64(dotW * dotH))
+	return frame, nil
+}
+
+// brailleRow fills row y of frame from the resolved dot samples and levels.
+// Transparent dots are never drawn, and a cell of nothing but is blank.
+func brailleRow(frame *Frame, samples []color.RGBA64, dots []int, y int) {
+	cols, dotW := frame.Width, frame.Width*2
+	for x := 0; x < cols; x++ {
+		pattern := rune(brailleBase)
+		var lit, all [3]uint32
+		litCount, visible := 0, 0
+
+		for dy := 0; dy < 4; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				i := (y*4+dy)*dotW + x*2 + dx
+				c := samples[i]
+				if transparent(c) {
+					continue
+				}
+				all[0] += uint32(c.R)
+				all[1] += uint32(c.G)
+				all[2] += uint32(c.B)
+				visible++
+
+				// Level 0 is the dark end, same as the densest glyph
+				if dots[i] == 0 {
+					pattern |= brailleDots[dy][dx]
+					lit[0] += uint32(c.R)
+					lit[1] += uint32(c.G)
+					lit[2] += uint32(c.B)
+					litCount++
+				}
+			}
+		}
+		if visible == 0 {
+			frame.Cells[y*cols+x] = Cell{Glyph: " "}
+			continue
+		}
+
+		// Color the cell after the dots that are actually drawn
+		sum, n := all, uint32(visible)
+		if litCount > 0 {
+			sum, n = lit, uint32(litCount)
+		}
+		frame.Cells[y*cols+x] = Cell{
+			Glyph: string(pattern),
+			Color: cellColor(color.RGBA64{
+				R: uint16(sum[0] / n),
+				G: uint16(sum[1] / n),
+				B: uint16(sum[2] / n),
+				A: 0xffff,
+			}),
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ascii
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// charset is a decoded ASCII set. The glyphs are split once per conversion so
+// multibyte sets (blocks, dots, sigma, cringe, sussy) map to whole characters
+// instead of stray UTF-8 bytes.
+type charset struct {
+	name   string
+	glyphs []string
+	lut    [256]string // gray value -> glyph, filled once in newCharset
+}
+
+func newCharset(name string) *charset {
+	cs := &charset{
+		name:   name,
+		glyphs: splitGlyphs(charsets[name]),
+	}
+	if len(cs.glyphs) == 0 {
+		cs.glyphs = []string{" "}
+	}
+
+	for gray := range cs.lut {
+		cs.lut[gray] = cs.glyphs[cs.index(uint8(gray))]
+	}
+	return cs
+}
+
+// index maps a gray value onto a glyph index, darkest glyph first.
+func (cs *charset) index(gray uint8) int {
+	index := int(gray) * (len(cs.glyphs) - 1) / 255
+	if index >= len(cs.glyphs) {
+		index = len(cs.glyphs) - 1
+	}
+	return index
+}
+
+func (cs *charset) glyph(gray uint8) string {
+	return cs.lut[gray]
+}
+
+// splitGlyphs breaks a set into user-perceived characters. Combining marks,
+// variation selectors, skin tone modifiers and zero width joiners stay glued
+// to the rune before them so emoji sequences are never torn apart.
+func splitGlyphs(set string) []string {
+	var glyphs []string
+	joinNext := false
+
+	for i := 0; i < len(set); {
+		r, size := utf8.DecodeRuneInString(set[i:])
+		part := set[i : i+size]
+		i += size
+
+		if r == utf8.RuneError && size <= 1 {
+			continue
+		}
+
+		if len(glyphs) > 0 && (joinNext || isGlyphExtender(r)) {
+			glyphs[len(glyphs)-1] += part
+			joinNext = r == '\u200d'
+			continue
+		}
+
+		glyphs = append(glyphs, part)
+		joinNext = false
+	}
+	return glyphs
+}
+
+func isGlyphExtender(r rune) bool {
+	switch {
+	case r == '\u200d': // zero width joiner
+		return true
+	case r >= '\ufe00' && r <= '\ufe0f': // variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // emoji skin tone modifiers
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
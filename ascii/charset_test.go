@@ -0,0 +1,75 @@
+package ascii
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplitGlyphs(t *testing.T) {
+	tests := []struct {
+		name string
+		set  string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"ascii", "@%# ", []string{"@", "%", "#", " "}},
+		{"multibyte", "█▓░ ", []string{"█", "▓", "░", " "}},
+		{"braille", "⣿⣀", []string{"⣿", "⣀"}},
+		{"emoji", "💀😭", []string{"💀", "😭"}},
+		{"combining mark", "e\u0301a", []string{"e\u0301", "a"}},
+		{"enclosing mark", "1\u20e32", []string{"1\u20e3", "2"}},
+		{"several marks", "a\u0323\u0300b", []string{"a\u0323\u0300", "b"}},
+		{"variation selector", "❤\ufe0f❤", []string{"❤\ufe0f", "❤"}},
+		{"skin tone", "\U0001f44d\U0001f3fd\U0001f44d", []string{"\U0001f44d\U0001f3fd", "\U0001f44d"}},
+		{"zwj sequence", "\U0001f468\u200d\U0001f469\u200d\U0001f467x", []string{"\U0001f468\u200d\U0001f469\u200d\U0001f467", "x"}},
+		{"zwj with selector", "\U0001f3f3\ufe0f\u200d\U0001f308 ", []string{"\U0001f3f3\ufe0f\u200d\U0001f308", " "}},
+		{"zwj with skin tone", "\U0001f9d1\U0001f3fd\u200d\U0001f4bb!", []string{"\U0001f9d1\U0001f3fd\u200d\U0001f4bb", "!"}},
+		{"leading mark", "\u0301a", []string{"\u0301", "a"}},
+		{"trailing zwj", "a\u200d", []string{"a\u200d"}},
+		{"invalid utf-8", "a\xffb\xc3", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitGlyphs(tt.set); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitGlyphs(%q) = %q, want %q", tt.set, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinCharsetsSplit(t *testing.T) {
+	want := map[string]int{
+		"default": 10,
+		"blocks":  9,
+		"dots":    15,
+		"sigma":   7,
+		"cringe":  5,
+		"sussy":   8,
+	}
+	for name, n := range want {
+		if got := len(newCharset(name).glyphs); got != n {
+			t.Errorf("charset %s has %d glyphs, want %d", name, got, n)
+		}
+	}
+}
+
+func TestCharsetAccessors(t *testing.T) {
+	names := CharsetNames()
+	if len(names) != len(charsets) || !sort.StringsAreSorted(names) {
+		t.Fatalf("CharsetNames() = %v, want every set sorted", names)
+	}
+	for _, name := range names {
+		if set, ok := Charset(name); !ok || set != charsets[name] {
+			t.Errorf("Charset(%q) = %q, %v", name, set, ok)
+		}
+	}
+	if _, ok := Charset("nope"); ok {
+		t.Error("Charset found a set that doesn't exist")
+	}
+
+	names[0] = "changed"
+	if CharsetNames()[0] == "changed" {
+		t.Error("CharsetNames shares its slice with callers")
+	}
+}
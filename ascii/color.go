@@ -0,0 +1,150 @@
+package ascii
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+)
+
+// ColorDepth is the ANSI color depth of colored output.
+type ColorDepth int
+
+const (
+	ColorNone ColorDepth = iota
+	Color16
+	Color256
+	ColorTrue
+)
+
+const sgrReset = "\033[0m"
+
+// ParseColorDepth parses a color mode name such as "256" or "truecolor".
+func ParseColorDepth(mode string) (ColorDepth, error) {
+	switch mode {
+	case "", "none", "off":
+		return ColorNone, nil
+	case "16", "basic":
+		return Color16, nil
+	case "256", "xterm":
+		return Color256, nil
+	case "truecolor", "24bit", "true":
+		return ColorTrue, nil
+	}
+	return ColorNone, fmt.Errorf("invalid color mode: %s", mode)
+}
+
+// ansi16Palette holds the usual xterm values for SGR 30-37 and 90-97.
+var ansi16Palette = [16]color.RGBA{
+	{0, 0, 0, 255}, {205, 0, 0, 255}, {0, 205, 0, 255}, {205, 205, 0, 255},
+	{0, 0, 238, 255}, {205, 0, 205, 255}, {0, 205, 205, 255}, {229, 229, 229, 255},
+	{127, 127, 127, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {255, 255, 0, 255},
+	{92, 92, 255, 255}, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255},
+}
+
+// cubeLevels are the channel values of the 6x6x6 xterm color cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// sgrForeground returns the SGR sequence selecting c as the foreground color.
+func sgrForeground(c color.Color, depth ColorDepth) string {
+	return sgrColor(c, depth, false)
+}
+
+// sgrBackground returns the SGR sequence selecting c as the background color.
+func sgrBackground(c color.Color, depth ColorDepth) string {
+	return sgrColor(c, depth, true)
+}
+
+func sgrColor(c color.Color, depth ColorDepth, background bool) string {
+	r, g, b := rgb8(c)
+
+	// Background codes are the foreground ones shifted by 10
+	base := 0
+	if background {
+		base = 10
+	}
+
+	switch depth {
+	case ColorTrue:
+		return "\033[" + strconv.Itoa(38+base) + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)) + "m"
+	case Color256:
+		return "\033[" + strconv.Itoa(38+base) + ";5;" + strconv.Itoa(xterm256Index(r, g, b)) + "m"
+	case Color16:
+		index := ansi16Index(r, g, b)
+		if index < 8 {
+			return "\033[" + strconv.Itoa(30+base+index) + "m"
+		}
+		return "\033[" + strconv.Itoa(90+base+index-8) + "m"
+	}
+	return ""
+}
+
+// sgrStyle returns the SGR sequence for a cell style, empty for plain cells.
+func sgrStyle(st cellStyle, depth ColorDepth) string {
+	sgr := ""
+	if st.hasFG {
+		sgr += sgrForeground(st.fg, depth)
+	}
+	if st.hasBG {
+		sgr += sgrBackground(st.bg, depth)
+	}
+	return sgr
+}
+
+func rgb8(c color.Color) (uint8, uint8, uint8) {
+	r, g, b, _ := c.RGBA()
+	return uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)
+}
+
+// xterm256Index picks the closest entry of the 256-color palette, comparing
+// the nearest cube color against the nearest step of the grayscale ramp.
+func xterm256Index(r, g, b uint8) int {
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// Grayscale ramp 232-255 runs from 8 to 238 in steps of 10
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayStep := (avg - 3) / 10
+	if grayStep < 0 {
+		grayStep = 0
+	}
+	if grayStep > 23 {
+		grayStep = 23
+	}
+	level := uint8(8 + 10*grayStep)
+	grayDist := colorDistance(r, g, b, level, level, level)
+
+	if grayDist < cubeDist {
+		return 232 + grayStep
+	}
+	return cube
+}
+
+func cubeIndex(v uint8) int {
+	if v < 48 {
+		return 0
+	}
+	if v < 115 {
+		return 1
+	}
+	return (int(v) - 35) / 40
+}
+
+func ansi16Index(r, g, b uint8) int {
+	best, bestDist := 0, -1
+	for i, p := range ansi16Palette {
+		dist := colorDistance(r, g, b, p.R, p.G, p.B)
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// colorDistance is a cheap perceptual distance weighting green the most.
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return 3*dr*dr + 4*dg*dg + 2*db*db
+}
@@ -0,0 +1,99 @@
+package ascii
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestCubeIndex(t *testing.T) {
+	// Each value goes to the nearest of 0, 95, 135, 175, 215 and 255
+	tests := []struct {
+		v    uint8
+		want int
+	}{
+		{0, 0}, {47, 0}, {48, 1}, {114, 1}, {115, 2},
+		{154, 2}, {155, 3}, {195, 4}, {235, 5}, {255, 5},
+	}
+	for _, tt := range tests {
+		if got := cubeIndex(tt.v); got != tt.want {
+			t.Errorf("cubeIndex(%d) = %d, want %d", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestXterm256Index(t *testing.T) {
+	tests := []struct {
+		name    string
+		r, g, b uint8
+		want    int
+	}{
+		{"black", 0, 0, 0, 16},
+		{"white", 255, 255, 255, 231},
+		{"red", 255, 0, 0, 196},
+		{"green", 0, 255, 0, 46},
+		{"blue", 0, 0, 255, 21},
+		{"cube entry", 95, 135, 175, 67},
+		{"dark red", 100, 0, 0, 52},
+		{"mid gray on the ramp", 128, 128, 128, 244},
+		{"darkest ramp step", 8, 8, 8, 232},
+		{"lightest ramp step", 238, 238, 238, 255},
+		{"near white stays in the cube", 250, 250, 250, 231},
+	}
+	for _, tt := range tests {
+		if got := xterm256Index(tt.r, tt.g, tt.b); got != tt.want {
+			t.Errorf("%s: xterm256Index(%d, %d, %d) = %d, want %d", tt.name, tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestANSI16Index(t *testing.T) {
+	tests := []struct {
+		name    string
+		r, g, b uint8
+		want    int
+	}{
+		{"black", 0, 0, 0, 0},
+		{"dark red", 205, 0, 0, 1},
+		{"near dark yellow", 200, 200, 0, 3},
+		{"dark blue", 0, 0, 238, 4},
+		{"light gray", 229, 229, 229, 7},
+		{"dark gray", 127, 127, 127, 8},
+		{"bright red", 255, 0, 0, 9},
+		{"bright blue", 92, 92, 255, 12},
+		{"white", 255, 255, 255, 15},
+	}
+	for _, tt := range tests {
+		if got := ansi16Index(tt.r, tt.g, tt.b); got != tt.want {
+			t.Errorf("%s: ansi16Index(%d, %d, %d) = %d, want %d", tt.name, tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSGRColor(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	darkRed := color.RGBA{205, 0, 0, 255}
+	tests := []struct {
+		name       string
+		c          color.Color
+		depth      ColorDepth
+		background bool
+		want       string
+	}{
+		{"truecolor", red, ColorTrue, false, "\033[38;2;255;0;0m"},
+		{"truecolor background", red, ColorTrue, true, "\033[48;2;255;0;0m"},
+		{"truecolor from gray", color.Gray{128}, ColorTrue, false, "\033[38;2;128;128;128m"},
+		{"256", red, Color256, false, "\033[38;5;196m"},
+		{"256 background", red, Color256, true, "\033[48;5;196m"},
+		{"16 normal", darkRed, Color16, false, "\033[31m"},
+		{"16 normal background", darkRed, Color16, true, "\033[41m"},
+		{"16 bright", red, Color16, false, "\033[91m"},
+		{"16 bright background", red, Color16, true, "\033[101m"},
+		{"16 white", color.White, Color16, false, "\033[97m"},
+		{"no color", red, ColorNone, false, ""},
+	}
+	for _, tt := range tests {
+		if got := sgrColor(tt.c, tt.depth, tt.background); got != tt.want {
+			t.Errorf("%s: sgrColor = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
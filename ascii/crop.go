@@ -0,0 +1,75 @@
+package ascii
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// ParseCrop parses a crop region given as "x,y,w,h". An empty string means
+// no cropping and yields an empty rectangle.
+func ParseCrop(spec string) (image.Rectangle, error) {
+	if spec == "" {
+		return image.Rectangle{}, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("invalid crop region: %s", spec)
+	}
+
+	var values [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("invalid crop region: %s", spec)
+		}
+		values[i] = v
+	}
+
+	if values[0] < 0 || values[1] < 0 || values[2] <= 0 || values[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("invalid crop region: %s", spec)
+	}
+	return image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3]), nil
+}
+
+// checkCrop makes sure a crop region overlaps an image with the given bounds.
+func checkCrop(crop, bounds image.Rectangle) error {
+	if crop.Empty() {
+		return nil
+	}
+	if crop.Add(bounds.Min).Intersect(bounds).Empty() {
+		return fmt.Errorf("crop region %d,%d,%d,%d is outside the %dx%d image",
+			crop.Min.X, crop.Min.Y, crop.Dx(), crop.Dy(), bounds.Dx(), bounds.Dy())
+	}
+	return nil
+}
+
+// croppedImage narrows the bounds of an image that has no SubImage method.
+type croppedImage struct {
+	image.Image
+	rect image.Rectangle
+}
+
+func (c *croppedImage) Bounds() image.Rectangle {
+	return c.rect
+}
+
+// cropImage selects the crop region, given relative to the top left corner of
+// the image, and clips it to the image bounds. The result keeps the original
+// coordinates, so its Bounds().Min is usually not the origin.
+func cropImage(img image.Image, crop image.Rectangle) image.Image {
+	bounds := img.Bounds()
+	rect := crop.Add(bounds.Min).Intersect(bounds)
+	if rect.Empty() {
+		return img
+	}
+
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	return &croppedImage{Image: img, rect: rect}
+}
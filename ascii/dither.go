@@ -0,0 +1,200 @@
+package ascii
+
+import "fmt"
+
+// ditherTap spreads part of the quantization error to a neighbour at
+// (dx, dy), relative to the pixel being processed in scan direction.
+type ditherTap struct {
+	dx, dy int
+	weight float64
+}
+
+// ditherKernel is an error diffusion matrix. Weights are divided by divisor,
+// kernels like Atkinson deliberately leave some of the error undistributed.
+type ditherKernel struct {
+	divisor float64
+	taps    []ditherTap
+}
+
+var ditherKernels = map[string]*ditherKernel{
+	"floyd-steinberg": {
+		divisor: 16,
+		taps: []ditherTap{
+			{1, 0, 7},
+			{-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+		},
+	},
+	"atkinson": {
+		divisor: 8,
+		taps: []ditherTap{
+			{1, 0, 1}, {2, 0, 1},
+			{-1, 1, 1}, {0, 1, 1}, {1, 1, 1},
+			{0, 2, 1},
+		},
+	},
+	"jjn": {
+		divisor: 48,
+		taps: []ditherTap{
+			{1, 0, 7}, {2, 0, 5},
+			{-2, 1, 3}, {-1, 1, 5}, {0, 1, 7}, {1, 1, 5}, {2, 1, 3},
+			{-2, 2, 1}, {-1, 2, 3}, {0, 2, 5}, {1, 2, 3}, {2, 2, 1},
+		},
+	},
+	"sierra": {
+		divisor: 32,
+		taps: []ditherTap{
+			{1, 0, 5}, {2, 0, 3},
+			{-2, 1, 2}, {-1, 1, 4}, {0, 1, 5}, {1, 1, 4}, {2, 1, 2},
+			{-1, 2, 2}, {0, 2, 3}, {1, 2, 2},
+		},
+	},
+}
+
+// ditherModes are the canonical dither names, aliases resolve to these.
+var ditherModes = []string{"none", "floyd-steinberg", "atkinson", "jjn", "sierra", "bayer", "noise"}
+
+// DitherModes returns the dither modes NormalizeDitherMode resolves to.
+func DitherModes() []string {
+	return append([]string(nil), ditherModes...)
+}
+
+// bayer8 is the 8x8 ordered dither threshold map, values 0-63.
+var bayer8 = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// NormalizeDitherMode resolves aliases and validates a --dither value.
+func NormalizeDitherMode(mode string) (string, error) {
+	switch mode {
+	case "", "none", "off":
+		return "none", nil
+	case "floyd-steinberg", "floyd", "fs":
+		return "floyd-steinberg", nil
+	case "jjn", "jarvis", "jarvis-judice-ninke":
+		return "jjn", nil
+	case "atkinson", "sierra", "bayer", "noise":
+		return mode, nil
+	case "ordered":
+		return "bayer", nil
+	case "random", "white-noise":
+		return "noise", nil
+	}
+	return "", fmt.Errorf("invalid dither mode: %s", mode)
+}
+
+// quantizer maps gray values onto one of levels evenly spaced output levels.
+// With a threshold set the output is binary and the threshold is the cutoff
+// instead of the midpoint.
+type quantizer struct {
+	levels    int
+	threshold int
+}
+
+func (q quantizer) levelValue(level int) float64 {
+	if q.levels <= 1 {
+		return 0
+	}
+	return float64(level) * 255 / float64(q.levels-1)
+}
+
+func (q quantizer) quantize(v float64) int {
+	if q.levels <= 1 {
+		return 0
+	}
+	if q.threshold > 0 {
+		if v < float64(q.threshold) {
+			return 0
+		}
+		return q.levels - 1
+	}
+	level := int(v*float64(q.levels-1)/255 + 0.5)
+	if level < 0 {
+		return 0
+	}
+	if level >= q.levels {
+		return q.levels - 1
+	}
+	return level
+}
+
+// noiseAt returns a pseudo-random threshold in [0, 1) for (x, y). It is a
+// splitmix64 hash of the position and seed rather than a stateful generator,
+// so the pattern only depends on the seed and not on how rows are scheduled.
+func noiseAt(seed int64, x, y int) float64 {
+	z := uint64(seed) + uint64(x)*0x9e3779b97f4a7c15 + uint64(y)*0xc2b2ae3d27d4eb4f
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return float64(z>>11) / (1 << 53)
+}
+
+// ditherGrid turns a width x height grid of gray values into output levels
+// using the given mode. Error diffusion runs serpentine, alternating the scan
+// direction every row to avoid the typical diagonal artifacts. Seed picks the
+// pattern of the noise mode and is ignored by the others.
+func ditherGrid(mode string, gray []uint8, width, height int, q quantizer, seed int64) []int {
+	levels := make([]int, len(gray))
+
+	if mode == "bayer" || mode == "noise" {
+		step := 255.0
+		if q.threshold == 0 && q.levels > 1 {
+			step = 255 / float64(q.levels-1)
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				var offset float64
+				if mode == "bayer" {
+					offset = (float64(bayer8[y%8][x%8])+0.5)/64 - 0.5
+				} else {
+					offset = noiseAt(seed, x, y) - 0.5
+				}
+				levels[y*width+x] = q.quantize(float64(gray[y*width+x]) + offset*step)
+			}
+		}
+		return levels
+	}
+
+	kernel, ok := ditherKernels[mode]
+	if !ok {
+		for i, v := range gray {
+			levels[i] = q.quantize(float64(v))
+		}
+		return levels
+	}
+
+	work := make([]float64, len(gray))
+	for i, v := range gray {
+		work[i] = float64(v)
+	}
+
+	for y := 0; y < height; y++ {
+		reverse := y%2 == 1
+		for i := 0; i < width; i++ {
+			x, dir := i, 1
+			if reverse {
+				x, dir = width-1-i, -1
+			}
+
+			idx := y*width + x
+			level := q.quantize(work[idx])
+			levels[idx] = level
+			quantErr := work[idx] - q.levelValue(level)
+
+			for _, tap := range kernel.taps {
+				nx, ny := x+tap.dx*dir, y+tap.dy
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				work[ny*width+nx] += quantErr * tap.weight / kernel.divisor
+			}
+		}
+	}
+	return levels
+}
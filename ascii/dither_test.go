@@ -0,0 +1,152 @@
+package ascii
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// meanLevel is the average gray value a grid of output levels stands for.
+func meanLevel(levels []int, q quantizer) float64 {
+	sum := 0.0
+	for _, level := range levels {
+		sum += q.levelValue(level)
+	}
+	return sum / float64(len(levels))
+}
+
+func meanGray(gray []uint8) float64 {
+	sum := 0.0
+	for _, v := range gray {
+		sum += float64(v)
+	}
+	return sum / float64(len(gray))
+}
+
+func constantGrid(v uint8, n int) []uint8 {
+	gray := make([]uint8, n)
+	for i := range gray {
+		gray[i] = v
+	}
+	return gray
+}
+
+func gradientGrid(width, height int) []uint8 {
+	gray := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray[y*width+x] = uint8(x * 255 / (width - 1))
+		}
+	}
+	return gray
+}
+
+func TestErrorDiffusionPreservesMean(t *testing.T) {
+	// Error that would land outside the grid is dropped, a big grid keeps
+	// that small next to the whole
+	const width, height = 256, 256
+	grids := map[string][]uint8{
+		"dark":     constantGrid(40, width*height),
+		"mid":      constantGrid(128, width*height),
+		"light":    constantGrid(200, width*height),
+		"gradient": gradientGrid(width, height),
+	}
+
+	for _, mode := range []string{"floyd-steinberg", "jjn", "sierra"} {
+		for name, gray := range grids {
+			for _, levels := range []int{2, 4, 10} {
+				q := quantizer{levels: levels}
+				got := meanLevel(ditherGrid(mode, gray, width, height, q, 0), q)
+				if want := meanGray(gray); math.Abs(got-want) > 1 {
+					t.Errorf("%s on %s grid with %d levels: mean %.2f, want %.2f", mode, name, levels, got, want)
+				}
+			}
+		}
+	}
+}
+
+// Atkinson drops a quarter of every error on purpose, which pushes the
+// extremes further out. It keeps midtones and the order of tones.
+func TestAtkinsonKeepsOrder(t *testing.T) {
+	const width, height = 128, 128
+	q := quantizer{levels: 2}
+	prev := -1.0
+	for _, v := range []uint8{0, 40, 80, 128, 170, 210, 255} {
+		got := meanLevel(ditherGrid("atkinson", constantGrid(v, width*height), width, height, q, 0), q)
+		if got < prev {
+			t.Errorf("constant %d gave mean %.2f, darker than the grid before it (%.2f)", v, got, prev)
+		}
+		if v == 128 && math.Abs(got-128) > 2 {
+			t.Errorf("midtone mean %.2f, want 128", got)
+		}
+		prev = got
+	}
+}
+
+func TestOrderedDitherPreservesMean(t *testing.T) {
+	const width, height = 64, 64
+	for _, mode := range []string{"bayer", "noise"} {
+		for _, v := range []uint8{40, 128, 200} {
+			q := quantizer{levels: 2}
+			got := meanLevel(ditherGrid(mode, constantGrid(v, width*height), width, height, q, 1), q)
+			if math.Abs(got-float64(v)) > 6 {
+				t.Errorf("%s on constant %d: mean %.2f", mode, v, got)
+			}
+		}
+	}
+}
+
+func TestDitherLevelsInRange(t *testing.T) {
+	const width, height = 33, 17
+	gray := gradientGrid(width, height)
+	for _, mode := range ditherModes {
+		for _, q := range []quantizer{{levels: 2}, {levels: 7}, {levels: 2, threshold: 100}} {
+			for i, level := range ditherGrid(mode, gray, width, height, q, 3) {
+				if level < 0 || level >= q.levels {
+					t.Fatalf("%s: level %d at %d out of range for %d levels", mode, level, i, q.levels)
+				}
+			}
+		}
+	}
+}
+
+func TestDitherExtremesStayPure(t *testing.T) {
+	const width, height = 16, 16
+	q := quantizer{levels: 5}
+	for _, mode := range ditherModes {
+		for _, v := range []uint8{0, 255} {
+			want := 0
+			if v == 255 {
+				want = q.levels - 1
+			}
+			for i, level := range ditherGrid(mode, constantGrid(v, width*height), width, height, q, 9) {
+				if level != want {
+					t.Fatalf("%s on constant %d: level %d at %d, want %d", mode, v, level, i, want)
+				}
+			}
+		}
+	}
+}
+
+func TestNoiseDitherFollowsSeed(t *testing.T) {
+	const width, height = 32, 32
+	gray := constantGrid(128, width*height)
+	q := quantizer{levels: 2}
+	a := ditherGrid("noise", gray, width, height, q, 42)
+	b := ditherGrid("noise", gray, width, height, q, 42)
+	c := ditherGrid("noise", gray, width, height, q, 43)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("same seed gave different patterns")
+	}
+	if reflect.DeepEqual(a, c) {
+		t.Error("different seeds gave the same pattern")
+	}
+}
+
+func TestThresholdWithoutDither(t *testing.T) {
+	q := quantizer{levels: 2, threshold: 100}
+	got := ditherGrid("none", []uint8{0, 99, 100, 255}, 4, 1, q, 0)
+	if want := []int{0, 0, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,509 @@
+package ascii
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cell is one character of rendered output together with the source color
+// it was sampled from. Block render modes also pick a background color.
+type Cell struct {
+	Glyph         string
+	Color         color.RGBA
+	Background    color.RGBA
+	HasBackground bool
+}
+
+// cellStyle is what an encoder has to switch between cells. Plain spaces and
+// transparent cells have no foreground since their color never shows.
+type cellStyle struct {
+	fg, bg       color.RGBA
+	hasFG, hasBG bool
+}
+
+func (cl Cell) style() cellStyle {
+	var st cellStyle
+	if cl.Glyph != " " && cl.Color.A != 0 {
+		st.fg, st.hasFG = cl.Color, true
+	}
+	if cl.HasBackground {
+		st.bg, st.hasBG = cl.Background, true
+	}
+	return st
+}
+
+// Frame is a rendered grid of cells, stored row by row.
+type Frame struct {
+	Width  int
+	Height int
+	Cells  []Cell
+	Delay  time.Duration
+}
+
+func newFrame(width, height int) *Frame {
+	return &Frame{
+		Width:  width,
+		Height: height,
+		Cells:  make([]Cell, width*height),
+	}
+}
+
+// Row returns the cells of row y.
+func (f *Frame) Row(y int) []Cell {
+	return f.Cells[y*f.Width : (y+1)*f.Width]
+}
+
+// WriteTo writes the frame as plain text, one line per row.
+func (f *Frame) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for y := 0; y < f.Height; y++ {
+		for _, cl := range f.Row(y) {
+			m, _ := bw.WriteString(cl.Glyph)
+			n += int64(m)
+		}
+		m, _ := bw.WriteString("\n")
+		n += int64(m)
+	}
+	return n, bw.Flush()
+}
+
+// String returns the frame as plain text, one line per row.
+func (f *Frame) String() string {
+	var out strings.Builder
+	f.WriteTo(&out)
+	return out.String()
+}
+
+// OutputInfo describes the whole output stream before any frame is written.
+type OutputInfo struct {
+	Title        string
+	Charset      string
+	ColorDepth   ColorDepth
+	Animated     bool
+	FrameHeaders bool
+
+	// Metadata describes the source image, like the camera that took it.
+	// Formats with a place for it (json) write it, the others ignore it.
+	Metadata map[string]string
+}
+
+// Encoder writes rendered frames in one output format. Begin is called once,
+// WriteFrame once per frame in display order and End once at the very end.
+// Encoders stream: every call writes its part of the output and flushes it,
+// so memory stays bounded by a single frame however many frames are written.
+type Encoder interface {
+	Begin(info OutputInfo) error
+	WriteFrame(frame *Frame) error
+	End() error
+}
+
+// formats lists the output formats NewEncoder understands.
+var formats = []string{"text", "ansi", "html", "svg", "json"}
+
+// Formats returns the output formats NewEncoder understands.
+func Formats() []string {
+	return append([]string(nil), formats...)
+}
+
+// FormatFromExtension guesses an output format from an output file name.
+func FormatFromExtension(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		return "html"
+	case ".svg":
+		return "svg"
+	case ".json":
+		return "json"
+	case ".ans", ".ansi":
+		return "ansi"
+	case ".txt":
+		return "text"
+	}
+	return ""
+}
+
+// ExtensionForFormat is the file extension outputs of format are written
+// with, the reverse of FormatFromExtension.
+func ExtensionForFormat(format string) string {
+	switch format {
+	case "html", "svg", "json":
+		return "." + format
+	case "ansi":
+		return ".ans"
+	}
+	return ".txt"
+}
+
+// NewEncoder returns an encoder for format writing to dst. Writes are buffered
+// and flushed at the end of Begin, every WriteFrame and End; bufio keeps the
+// first write error, so that flush is where it gets reported.
+func NewEncoder(format string, dst io.Writer) (Encoder, error) {
+	w := bufio.NewWriter(dst)
+	switch format {
+	case "text", "":
+		return &textEncoder{w: w}, nil
+	case "ansi":
+		return &textEncoder{w: w, forceColor: true}, nil
+	case "html":
+		return &htmlEncoder{w: w}, nil
+	case "svg":
+		return &svgEncoder{w: w}, nil
+	case "json":
+		return &jsonEncoder{w: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported output format: %s", format)
+}
+
+// colorRuns calls fn for each run of cells sharing a style, with the index of
+// its first cell and the cell count. Plain spaces never start a new run unless
+// they have to end a background.
+func colorRuns(row []Cell, fn func(st cellStyle, glyphs string, start, n int)) {
+	var run strings.Builder
+	var current cellStyle
+	start := 0
+
+	for x, cl := range row {
+		st := cl.style()
+		if st != current && (st.hasFG || st.hasBG || current.hasBG) {
+			if x > start {
+				fn(current, run.String(), start, x-start)
+			}
+			run.Reset()
+			current = st
+			start = x
+		}
+		run.WriteString(cl.Glyph)
+	}
+	if len(row) > start {
+		fn(current, run.String(), start, len(row)-start)
+	}
+}
+
+// cssStyle returns the inline style for a run, empty for plain runs.
+func cssStyle(st cellStyle) string {
+	var parts []string
+	if st.hasFG {
+		parts = append(parts, "color:"+hexColor(st.fg))
+	}
+	if st.hasBG {
+		parts = append(parts, "background-color:"+hexColor(st.bg))
+	}
+	return strings.Join(parts, ";")
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// textEncoder writes plain text, with SGR escapes when color is enabled.
+// The ansi format is the same encoder with color forced on.
+type textEncoder struct {
+	w          *bufio.Writer
+	forceColor bool
+	info       OutputInfo
+	frames     int
+}
+
+func (e *textEncoder) Begin(info OutputInfo) error {
+	e.info = info
+	if e.forceColor && e.info.ColorDepth == ColorNone {
+		e.info.ColorDepth = ColorTrue
+	}
+	return nil
+}
+
+func (e *textEncoder) WriteFrame(frame *Frame) error {
+	out := e.w
+	e.frames++
+
+	if e.info.FrameHeaders {
+		fmt.Fprintf(out, "=== FRAME %d ===\n", e.frames)
+	}
+
+	for y := 0; y < frame.Height; y++ {
+		row := frame.Row(y)
+		if e.info.ColorDepth == ColorNone {
+			for _, cl := range row {
+				out.WriteString(cl.Glyph)
+			}
+			out.WriteString("\n")
+			continue
+		}
+
+		// Only emit a new SGR sequence when the quantized color actually
+		// changes, plain spaces keep whatever color is active
+		lastSGR, lastBG := "", false
+		colorRuns(row, func(st cellStyle, glyphs string, _, _ int) {
+			if sgr := sgrStyle(st, e.info.ColorDepth); sgr != lastSGR {
+				if (lastBG && !st.hasBG) || sgr == "" {
+					out.WriteString(sgrReset)
+				}
+				out.WriteString(sgr)
+				lastSGR, lastBG = sgr, st.hasBG
+			}
+			out.WriteString(glyphs)
+		})
+		if lastSGR != "" {
+			out.WriteString(sgrReset)
+		}
+		out.WriteString("\n")
+	}
+
+	if e.info.FrameHeaders {
+		out.WriteString("\n")
+	}
+	return out.Flush()
+}
+
+func (e *textEncoder) End() error {
+	return nil
+}
+
+// htmlEncoder writes a standalone page with one monospace <pre> per frame.
+type htmlEncoder struct {
+	w    *bufio.Writer
+	info OutputInfo
+}
+
+func (e *htmlEncoder) Begin(info OutputInfo) error {
+	e.info = info
+	fmt.Fprintf(e.w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { background: #000; color: #fff; }
+pre { font-family: monospace; line-height: 1; letter-spacing: 0; }
+</style>
+</head>
+<body>
+`, html.EscapeString(info.Title))
+	return e.w.Flush()
+}
+
+func (e *htmlEncoder) WriteFrame(frame *Frame) error {
+	out := e.w
+	out.WriteString("<pre>")
+	for y := 0; y < frame.Height; y++ {
+		row := frame.Row(y)
+		if e.info.ColorDepth == ColorNone {
+			for _, cl := range row {
+				out.WriteString(html.EscapeString(cl.Glyph))
+			}
+		} else {
+			colorRuns(row, func(st cellStyle, glyphs string, _, _ int) {
+				if style := cssStyle(st); style != "" {
+					fmt.Fprintf(out, `<span style="%s">%s</span>`, style, html.EscapeString(glyphs))
+				} else {
+					out.WriteString(html.EscapeString(glyphs))
+				}
+			})
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("</pre>\n")
+	return out.Flush()
+}
+
+func (e *htmlEncoder) End() error {
+	e.w.WriteString("</body>\n</html>\n")
+	return e.w.Flush()
+}
+
+// svg cell metrics for a 12px monospace font
+const (
+	svgFontSize   = 12
+	svgCharWidth  = 7.2
+	svgLineHeight = 14
+)
+
+// svgEncoder writes one <text> element per row. Animated input becomes one
+// group per frame, each revealed on top of the previous one at its start time.
+type svgEncoder struct {
+	w       *bufio.Writer
+	info    OutputInfo
+	started bool
+	frames  int
+	elapsed time.Duration
+}
+
+func (e *svgEncoder) Begin(info OutputInfo) error {
+	e.info = info
+	return nil
+}
+
+func (e *svgEncoder) WriteFrame(frame *Frame) error {
+	out := e.w
+	width := float64(frame.Width) * svgCharWidth
+	height := frame.Height * svgLineHeight
+
+	if !e.started {
+		// The first frame decides the canvas size
+		fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%.1f" height="%d" viewBox="0 0 %.1f %d" font-family="monospace" font-size="%d">`+"\n",
+			width, height, width, height, svgFontSize)
+		e.started = true
+	}
+
+	if e.info.Animated {
+		visibility := "hidden"
+		if e.frames == 0 {
+			visibility = "visible"
+		}
+		fmt.Fprintf(out, `<g visibility="%s">`+"\n", visibility)
+		if e.frames > 0 {
+			fmt.Fprintf(out, `<set attributeName="visibility" to="visible" begin="%dms"/>`+"\n", e.elapsed.Milliseconds())
+		}
+	} else {
+		out.WriteString("<g>\n")
+	}
+	fmt.Fprintf(out, `<rect width="100%%" height="100%%" fill="#000"/>`+"\n")
+
+	for y := 0; y < frame.Height; y++ {
+		row := frame.Row(y)
+		if e.info.ColorDepth == ColorNone {
+			fmt.Fprintf(out, `<text x="0" y="%d" xml:space="preserve" fill="#fff">`, (y+1)*svgLineHeight-3)
+			for _, cl := range row {
+				out.WriteString(html.EscapeString(cl.Glyph))
+			}
+			out.WriteString("</text>\n")
+			continue
+		}
+
+		// SVG text has no background, so backgrounds are rects behind the row
+		var text strings.Builder
+		colorRuns(row, func(st cellStyle, glyphs string, start, n int) {
+			if st.hasBG {
+				fmt.Fprintf(out, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n",
+					float64(start)*svgCharWidth, y*svgLineHeight, float64(n)*svgCharWidth, svgLineHeight, hexColor(st.bg))
+			}
+			if st.hasFG {
+				fmt.Fprintf(&text, `<tspan fill="%s">%s</tspan>`, hexColor(st.fg), html.EscapeString(glyphs))
+			} else {
+				text.WriteString(html.EscapeString(glyphs))
+			}
+		})
+		fmt.Fprintf(out, `<text x="0" y="%d" xml:space="preserve" fill="#fff">%s</text>`+"\n", (y+1)*svgLineHeight-3, text.String())
+	}
+	out.WriteString("</g>\n")
+	e.frames++
+	e.elapsed += frame.Delay
+	return out.Flush()
+}
+
+func (e *svgEncoder) End() error {
+	if !e.started {
+		e.w.WriteString(`<svg xmlns="http://www.w3.org/2000/svg"/>` + "\n")
+	} else {
+		e.w.WriteString("</svg>\n")
+	}
+	return e.w.Flush()
+}
+
+// jsonFrame is the JSON shape of a single frame.
+type jsonFrame struct {
+	Index       int        `json:"index"`
+	Width       int        `json:"width"`
+	Height      int        `json:"height"`
+	DelayMS     int64      `json:"delay_ms,omitempty"`
+	Rows        []string   `json:"rows"`
+	Colors      [][]string `json:"colors,omitempty"`
+	Backgrounds [][]string `json:"backgrounds,omitempty"`
+}
+
+// jsonEncoder writes a single object whose frames array is filled in as
+// frames arrive, so nothing but the current frame is held in memory.
+type jsonEncoder struct {
+	w      *bufio.Writer
+	info   OutputInfo
+	frames int
+	width  int
+	height int
+}
+
+func (e *jsonEncoder) Begin(info OutputInfo) error {
+	e.info = info
+	charset, err := json.Marshal(info.Charset)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.w, "{\"charset\":%s,\"animated\":%t,", charset, info.Animated)
+	if len(info.Metadata) > 0 {
+		metadata, err := json.Marshal(info.Metadata)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.w, "\"metadata\":%s,", metadata)
+	}
+	e.w.WriteString("\"frames\":[")
+	return e.w.Flush()
+}
+
+func (e *jsonEncoder) WriteFrame(frame *Frame) error {
+	jf := jsonFrame{
+		Index:   e.frames,
+		Width:   frame.Width,
+		Height:  frame.Height,
+		DelayMS: frame.Delay.Milliseconds(),
+		Rows:    make([]string, frame.Height),
+	}
+	if e.info.ColorDepth != ColorNone {
+		jf.Colors = make([][]string, frame.Height)
+		for _, cl := range frame.Cells {
+			if cl.HasBackground {
+				jf.Backgrounds = make([][]string, frame.Height)
+				break
+			}
+		}
+	}
+
+	for y := 0; y < frame.Height; y++ {
+		var row strings.Builder
+		for x, cl := range frame.Row(y) {
+			row.WriteString(cl.Glyph)
+			if jf.Colors != nil {
+				if x == 0 {
+					jf.Colors[y] = make([]string, frame.Width)
+				}
+				if cl.Color.A != 0 {
+					jf.Colors[y][x] = hexColor(cl.Color)
+				}
+			}
+			if jf.Backgrounds != nil {
+				if x == 0 {
+					jf.Backgrounds[y] = make([]string, frame.Width)
+				}
+				if cl.HasBackground {
+					jf.Backgrounds[y][x] = hexColor(cl.Background)
+				}
+			}
+		}
+		jf.Rows[y] = row.String()
+	}
+
+	data, err := json.Marshal(jf)
+	if err != nil {
+		return err
+	}
+	if e.frames > 0 {
+		e.w.WriteString(",")
+	}
+	e.frames++
+	e.width, e.height = frame.Width, frame.Height
+
+	e.w.Write(data)
+	return e.w.Flush()
+}
+
+func (e *jsonEncoder) End() error {
+	fmt.Fprintf(e.w, "],\"frame_count\":%d,\"width\":%d,\"height\":%d}\n", e.frames, e.width, e.height)
+	return e.w.Flush()
+}
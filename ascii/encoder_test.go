@@ -0,0 +1,249 @@
+package ascii
+
+import (
+	"encoding/json"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	fgRed    = Cell{Glyph: "#", Color: color.RGBA{255, 0, 0, 255}}
+	fgBlue   = Cell{Glyph: "@", Color: color.RGBA{0, 0, 255, 255}}
+	redSpace = Cell{Glyph: " ", Color: color.RGBA{255, 0, 0, 255}}
+	onRed    = Cell{Glyph: "x", Color: color.RGBA{255, 255, 255, 255}, Background: color.RGBA{255, 0, 0, 255}, HasBackground: true}
+	escaped  = Cell{Glyph: "<", Color: color.RGBA{0, 255, 0, 255}}
+	amp      = Cell{Glyph: "&", Color: color.RGBA{255, 255, 255, 255}}
+)
+
+// cellFrame builds a frame from rows of cells.
+func cellFrame(delay time.Duration, rows ...[]Cell) *Frame {
+	f := newFrame(len(rows[0]), len(rows))
+	for y, row := range rows {
+		copy(f.Row(y), row)
+	}
+	f.Delay = delay
+	return f
+}
+
+// glyphFrame builds an uncolored frame, one string per row.
+func glyphFrame(rows ...string) *Frame {
+	cells := make([][]Cell, len(rows))
+	for y, row := range rows {
+		for _, r := range row {
+			cells[y] = append(cells[y], Cell{Glyph: string(r)})
+		}
+	}
+	return cellFrame(0, cells...)
+}
+
+func encode(t *testing.T, format string, info OutputInfo, frames ...*Frame) string {
+	t.Helper()
+	var out strings.Builder
+	enc, err := NewEncoder(format, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Begin(info); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range frames {
+		if err := enc.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.End(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestEncoders(t *testing.T) {
+	colored := cellFrame(0,
+		[]Cell{fgRed, fgRed, redSpace, fgRed, fgBlue},
+		[]Cell{onRed, redSpace, amp, escaped, redSpace},
+	)
+
+	tests := []struct {
+		name   string
+		format string
+		info   OutputInfo
+		frames []*Frame
+		want   string
+	}{
+		{
+			name:   "text",
+			format: "text",
+			frames: []*Frame{glyphFrame("ab", "cd")},
+			want:   "ab\ncd\n",
+		},
+		{
+			name:   "text with frame headers",
+			format: "text",
+			info:   OutputInfo{FrameHeaders: true},
+			frames: []*Frame{glyphFrame("a"), glyphFrame("b")},
+			want:   "=== FRAME 1 ===\na\n\n=== FRAME 2 ===\nb\n\n",
+		},
+		{
+			// A run only ends where the color changes, spaces stay inside it,
+			// and a background is reset before the cells after it
+			name:   "ansi coalesces runs and resets",
+			format: "ansi",
+			frames: []*Frame{colored},
+			want: "\033[38;2;255;0;0m## #\033[38;2;0;0;255m@\033[0m\n" +
+				"\033[38;2;255;255;255m\033[48;2;255;0;0mx\033[0m \033[38;2;255;255;255m&\033[38;2;0;255;0m< \033[0m\n",
+		},
+		{
+			name:   "ansi at 256 colors",
+			format: "ansi",
+			info:   OutputInfo{ColorDepth: Color256},
+			frames: []*Frame{cellFrame(0, []Cell{fgRed, fgBlue})},
+			want:   "\033[38;5;196m#\033[38;5;21m@\033[0m\n",
+		},
+		{
+			name:   "html escapes glyphs and the title",
+			format: "html",
+			info:   OutputInfo{Title: "a<b&c"},
+			frames: []*Frame{glyphFrame("<&>")},
+			want: `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>a&lt;b&amp;c</title>
+<style>
+body { background: #000; color: #fff; }
+pre { font-family: monospace; line-height: 1; letter-spacing: 0; }
+</style>
+</head>
+<body>
+<pre>&lt;&amp;&gt;
+</pre>
+</body>
+</html>
+`,
+		},
+		{
+			name:   "html colors runs with spans",
+			format: "html",
+			info:   OutputInfo{ColorDepth: ColorTrue},
+			frames: []*Frame{colored},
+			want: "<pre>" +
+				`<span style="color:#ff0000">## #</span><span style="color:#0000ff">@</span>` + "\n" +
+				`<span style="color:#ffffff;background-color:#ff0000">x</span> <span style="color:#ffffff">&amp;</span><span style="color:#00ff00">&lt; </span>` + "\n" +
+				"</pre>\n",
+		},
+		{
+			name:   "svg escapes glyphs",
+			format: "svg",
+			frames: []*Frame{glyphFrame("<&")},
+			want: `<svg xmlns="http://www.w3.org/2000/svg" width="14.4" height="14" viewBox="0 0 14.4 14" font-family="monospace" font-size="12">
+<g>
+<rect width="100%" height="100%" fill="#000"/>
+<text x="0" y="11" xml:space="preserve" fill="#fff">&lt;&amp;</text>
+</g>
+</svg>
+`,
+		},
+		{
+			name:   "svg draws backgrounds behind colored text",
+			format: "svg",
+			info:   OutputInfo{ColorDepth: ColorTrue},
+			frames: []*Frame{cellFrame(0, []Cell{onRed, escaped, amp})},
+			want: `<svg xmlns="http://www.w3.org/2000/svg" width="21.6" height="14" viewBox="0 0 21.6 14" font-family="monospace" font-size="12">
+<g>
+<rect width="100%" height="100%" fill="#000"/>
+<rect x="0.0" y="0" width="7.2" height="14" fill="#ff0000"/>
+<text x="0" y="11" xml:space="preserve" fill="#fff"><tspan fill="#ffffff">x</tspan><tspan fill="#00ff00">&lt;</tspan><tspan fill="#ffffff">&amp;</tspan></text>
+</g>
+</svg>
+`,
+		},
+		{
+			// Each frame is revealed when all the ones before it have played
+			name:   "svg animation timing",
+			format: "svg",
+			info:   OutputInfo{Animated: true},
+			frames: []*Frame{
+				cellFrame(100*time.Millisecond, []Cell{{Glyph: "a"}}),
+				cellFrame(250*time.Millisecond, []Cell{{Glyph: "b"}}),
+				cellFrame(50*time.Millisecond, []Cell{{Glyph: "c"}}),
+			},
+			want: `<svg xmlns="http://www.w3.org/2000/svg" width="7.2" height="14" viewBox="0 0 7.2 14" font-family="monospace" font-size="12">
+<g visibility="visible">
+<rect width="100%" height="100%" fill="#000"/>
+<text x="0" y="11" xml:space="preserve" fill="#fff">a</text>
+</g>
+<g visibility="hidden">
+<set attributeName="visibility" to="visible" begin="100ms"/>
+<rect width="100%" height="100%" fill="#000"/>
+<text x="0" y="11" xml:space="preserve" fill="#fff">b</text>
+</g>
+<g visibility="hidden">
+<set attributeName="visibility" to="visible" begin="350ms"/>
+<rect width="100%" height="100%" fill="#000"/>
+<text x="0" y="11" xml:space="preserve" fill="#fff">c</text>
+</g>
+</svg>
+`,
+		},
+		{
+			name:   "svg with no frames",
+			format: "svg",
+			want:   `<svg xmlns="http://www.w3.org/2000/svg"/>` + "\n",
+		},
+		{
+			name:   "json",
+			format: "json",
+			info:   OutputInfo{Charset: `@"<`, Animated: true, ColorDepth: ColorTrue, Metadata: map[string]string{"model": "X"}},
+			frames: []*Frame{
+				cellFrame(100*time.Millisecond, []Cell{fgRed, {Glyph: "."}}),
+				cellFrame(0, []Cell{onRed, redSpace}),
+			},
+			want: `{"charset":"@\"\u003c","animated":true,"metadata":{"model":"X"},"frames":[` +
+				`{"index":0,"width":2,"height":1,"delay_ms":100,"rows":["#."],"colors":[["#ff0000",""]]},` +
+				`{"index":1,"width":2,"height":1,"rows":["x "],"colors":[["#ffffff","#ff0000"]],"backgrounds":[["#ff0000",""]]}` +
+				`],"frame_count":2,"width":2,"height":1}` + "\n",
+		},
+		{
+			name:   "json without color",
+			format: "json",
+			frames: []*Frame{glyphFrame("ab", "cd")},
+			want:   `{"charset":"","animated":false,"frames":[{"index":0,"width":2,"height":2,"rows":["ab","cd"]}],"frame_count":1,"width":2,"height":2}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encode(t, tt.format, tt.info, tt.frames...)
+			if tt.format == "html" && tt.info.ColorDepth != ColorNone {
+				// Only the frame is compared, the page around it is tested above
+				got = got[strings.Index(got, "<pre>"):strings.Index(got, "</body>")]
+			}
+			if got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+			if tt.format == "json" && !json.Valid([]byte(got)) {
+				t.Error("output is not valid JSON")
+			}
+		})
+	}
+}
+
+func TestJSONFrameCount(t *testing.T) {
+	frames := []*Frame{glyphFrame("a"), glyphFrame("b"), glyphFrame("c")}
+	var doc struct {
+		Frames     []jsonFrame `json:"frames"`
+		FrameCount int         `json:"frame_count"`
+	}
+	if err := json.Unmarshal([]byte(encode(t, "json", OutputInfo{}, frames...)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.FrameCount != len(frames) || len(doc.Frames) != len(frames) {
+		t.Fatalf("frame_count %d with %d frames, want %d", doc.FrameCount, len(doc.Frames), len(frames))
+	}
+	for i, f := range doc.Frames {
+		if f.Index != i || f.Rows[0] != string(rune('a'+i)) {
+			t.Errorf("frame %d: %+v", i, f)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+)
+
+// Compositor rebuilds the full logical screen of an animated GIF frame by
+// frame. Optimized GIFs only store the part of each frame that changed, so a
+// frame on its own is just a fragment. Frames must be requested in order.
+type Compositor struct {
+	g          *gif.GIF
+	canvas     *image.RGBA
+	saved      *image.RGBA // canvas before the last frame, for DisposalPrevious
+	background color.Color
+	next       int
+}
+
+// NewCompositor starts compositing g from an empty logical screen.
+func NewCompositor(g *gif.GIF) *Compositor {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		// Broken logical screen size, fall back to the union of all frames
+		for _, frame := range g.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+
+	gc := &Compositor{
+		g:          g,
+		canvas:     image.NewRGBA(bounds),
+		background: gifBackground(g),
+	}
+	draw.Draw(gc.canvas, bounds, image.NewUniform(gc.background), image.Point{}, draw.Src)
+	return gc
+}
+
+// gifBackground picks the color that DisposalBackground clears to. Animations
+// with transparency are cleared to transparent like browsers do, the others
+// get the BackgroundIndex entry of the global color table.
+func gifBackground(g *gif.GIF) color.Color {
+	if len(g.Image) > 0 {
+		for _, c := range g.Image[0].Palette {
+			if _, _, _, a := c.RGBA(); a == 0 {
+				return color.Transparent
+			}
+		}
+	}
+
+	if palette, ok := g.Config.ColorModel.(color.Palette); ok && int(g.BackgroundIndex) < len(palette) {
+		return palette[g.BackgroundIndex]
+	}
+	return color.Transparent
+}
+
+// Bounds returns the logical screen the frames are composited onto.
+func (gc *Compositor) Bounds() image.Rectangle {
+	return gc.canvas.Bounds()
+}
+
+// disposal returns the disposal method of frame i, DisposalNone if unset.
+func (gc *Compositor) disposal(i int) byte {
+	if i < len(gc.g.Disposal) {
+		return gc.g.Disposal[i]
+	}
+	return gif.DisposalNone
+}
+
+// Next composites the next frame and returns a copy of the full canvas.
+func (gc *Compositor) Next() *image.RGBA {
+	i := gc.next
+	gc.next++
+
+	// Undo the previous frame as its disposal method asks
+	if i > 0 {
+		prev := gc.g.Image[i-1].Bounds()
+		switch gc.disposal(i - 1) {
+		case gif.DisposalBackground:
+			draw.Draw(gc.canvas, prev, image.NewUniform(gc.background), image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			if gc.saved != nil {
+				draw.Draw(gc.canvas, prev, gc.saved, prev.Min, draw.Src)
+			}
+		}
+	}
+
+	frame := gc.g.Image[i]
+	if gc.disposal(i) == gif.DisposalPrevious {
+		gc.saved = cloneRGBA(gc.canvas)
+	}
+
+	// Transparent palette entries have zero alpha, so Over keeps the canvas
+	draw.Draw(gc.canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+	return cloneRGBA(gc.canvas)
+}
+
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := &image.RGBA{
+		Pix:    make([]uint8, len(img.Pix)),
+		Stride: img.Stride,
+		Rect:   img.Rect,
+	}
+	copy(clone.Pix, img.Pix)
+	return clone
+}
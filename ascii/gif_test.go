@@ -0,0 +1,178 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"strings"
+	"testing"
+)
+
+// gifColors names the colors of the test palettes, '.' is transparent.
+var gifColors = map[byte]color.RGBA{
+	'k': {0, 0, 0, 255},
+	'r': {255, 0, 0, 255},
+	'g': {0, 255, 0, 255},
+	'b': {0, 0, 255, 255},
+	'w': {255, 255, 255, 255},
+	'.': {},
+}
+
+// gifPalette builds a palette whose index i is the color named by names[i].
+func gifPalette(names string) color.Palette {
+	var p color.Palette
+	for i := range names {
+		p = append(p, gifColors[names[i]])
+	}
+	return p
+}
+
+// gifFrame builds a frame over r with the palette named by names, from one
+// color name per pixel, row-major, with rows separated by '/'.
+func gifFrame(r image.Rectangle, names, pixels string) *image.Paletted {
+	frame := image.NewPaletted(r, gifPalette(names))
+	pixels = strings.ReplaceAll(pixels, "/", "")
+	for i := range pixels {
+		frame.Pix[i] = uint8(strings.IndexByte(names, pixels[i]))
+	}
+	return frame
+}
+
+func TestCompositor(t *testing.T) {
+	const opaque, clear = "krgbw", "krgbw."
+
+	tests := []struct {
+		name     string
+		config   image.Config
+		bgIndex  byte
+		frames   []*image.Paletted
+		disposal []byte
+		want     []string // the canvas after each frame, like gifFrame
+	}{
+		{
+			name:   "disposal none keeps offset frames on top",
+			config: image.Config{ColorModel: gifPalette(opaque), Width: 3, Height: 2},
+			frames: []*image.Paletted{
+				gifFrame(image.Rect(0, 0, 3, 2), opaque, "rrr/rrr"),
+				gifFrame(image.Rect(1, 0, 2, 1), opaque, "g"),
+				gifFrame(image.Rect(2, 1, 3, 2), opaque, "b"),
+			},
+			want: []string{"rrr/rrr", "rgr/rrr", "rgr/rrb"},
+		},
+		{
+			name:    "disposal background clears to the background index",
+			config:  image.Config{ColorModel: gifPalette(opaque), Width: 3, Height: 2},
+			bgIndex: 4,
+			frames: []*image.Paletted{
+				gifFrame(image.Rect(0, 0, 2, 2), opaque, "rr/rr"),
+				gifFrame(image.Rect(0, 0, 1, 1), opaque, "g"),
+			},
+			disposal: []byte{gif.DisposalBackground, gif.DisposalNone},
+			want:     []string{"rrw/rrw", "gww/www"},
+		},
+		{
+			name:   "disposal previous restores what was under the frame",
+			config: image.Config{ColorModel: gifPalette(opaque), Width: 3, Height: 2},
+			frames: []*image.Paletted{
+				gifFrame(image.Rect(0, 0, 3, 2), opaque, "rrr/rrr"),
+				gifFrame(image.Rect(0, 0, 2, 1), opaque, "gg"),
+				gifFrame(image.Rect(2, 1, 3, 2), opaque, "b"),
+			},
+			disposal: []byte{gif.DisposalNone, gif.DisposalPrevious},
+			want:     []string{"rrr/rrr", "ggr/rrr", "rrr/rrb"},
+		},
+		{
+			name:   "transparent indices show the canvas through",
+			config: image.Config{ColorModel: gifPalette(clear), Width: 3, Height: 2},
+			frames: []*image.Paletted{
+				gifFrame(image.Rect(0, 0, 3, 2), clear, "rrr/rrr"),
+				gifFrame(image.Rect(0, 0, 3, 2), clear, "g.g/..b"),
+			},
+			want: []string{"rrr/rrr", "grg/rrb"},
+		},
+		{
+			name:    "transparency clears to transparent, not the background index",
+			config:  image.Config{ColorModel: gifPalette(clear), Width: 3, Height: 2},
+			bgIndex: 4,
+			frames: []*image.Paletted{
+				gifFrame(image.Rect(0, 0, 2, 1), clear, "rr"),
+				gifFrame(image.Rect(2, 1, 3, 2), clear, "b"),
+			},
+			disposal: []byte{gif.DisposalBackground},
+			want:     []string{"rr./...", ".../..b"},
+		},
+		{
+			name: "broken screen size falls back to the frames",
+			frames: []*image.Paletted{
+				gifFrame(image.Rect(1, 1, 3, 2), opaque, "rr"),
+				gifFrame(image.Rect(2, 1, 4, 2), opaque, "gg"),
+			},
+			want: []string{"rr.", "rgg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &gif.GIF{
+				Image:           tt.frames,
+				Delay:           make([]int, len(tt.frames)),
+				Disposal:        tt.disposal,
+				Config:          tt.config,
+				BackgroundIndex: tt.bgIndex,
+			}
+			gc := NewCompositor(g)
+
+			// Every canvas is taken before comparing, so each must be a copy
+			var canvases []*image.RGBA
+			for range tt.frames {
+				canvases = append(canvases, gc.Next())
+			}
+			for i, canvas := range canvases {
+				checkCanvas(t, i, canvas, tt.want[i])
+			}
+		})
+	}
+}
+
+func checkCanvas(t *testing.T, frame int, canvas *image.RGBA, want string) {
+	t.Helper()
+	rows := strings.Split(want, "/")
+	b := canvas.Bounds()
+	if b.Dx() != len(rows[0]) || b.Dy() != len(rows) {
+		t.Fatalf("frame %d: canvas %v, want %dx%d", frame, b, len(rows[0]), len(rows))
+	}
+	for y, row := range rows {
+		for x := range row {
+			got := canvas.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			if c := gifColors[row[x]]; got != c && !(got.A == 0 && c.A == 0) {
+				t.Errorf("frame %d: pixel %d,%d = %v, want %c", frame, x, y, got, row[x])
+			}
+		}
+	}
+}
+
+func TestGIFBackground(t *testing.T) {
+	opaque := gifPalette("krgbw")
+	tests := []struct {
+		name    string
+		model   color.Model
+		index   byte
+		palette color.Palette // of the first frame
+		want    color.Color
+	}{
+		{"background index", opaque, 2, opaque, gifColors['g']},
+		{"index past the palette", opaque, 9, opaque, color.Transparent},
+		{"no global palette", nil, 2, opaque, color.Transparent},
+		{"first frame has transparency", opaque, 2, gifPalette("kr."), color.Transparent},
+	}
+	for _, tt := range tests {
+		g := &gif.GIF{
+			Image:           []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 1, 1), tt.palette)},
+			Config:          image.Config{ColorModel: tt.model, Width: 1, Height: 1},
+			BackgroundIndex: tt.index,
+		}
+		if got := gifBackground(g); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
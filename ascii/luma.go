@@ -0,0 +1,153 @@
+package ascii
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// lumaModels are the ways a color is reduced to the brightness glyphs are
+// picked by.
+var lumaModels = []string{"rec601", "rec709", "rec2020", "perceptual"}
+
+// LumaModels returns the luma models NormalizeLumaModel resolves to.
+func LumaModels() []string {
+	return append([]string(nil), lumaModels...)
+}
+
+// NormalizeLumaModel resolves aliases and validates a --luma value.
+func NormalizeLumaModel(model string) (string, error) {
+	switch model {
+	case "", "rec601", "601":
+		return "rec601", nil
+	case "rec709", "709", "srgb":
+		return "rec709", nil
+	case "rec2020", "2020":
+		return "rec2020", nil
+	case "perceptual", "lstar", "cie":
+		return "perceptual", nil
+	}
+	return "", fmt.Errorf("invalid luma model: %s", model)
+}
+
+// lumaWeights are the red, green and blue weights of each model. Perceptual
+// weighs by the sRGB primaries like rec709 and differs in how it encodes.
+var lumaWeights = map[string][3]float64{
+	"rec601":     {0.299, 0.587, 0.114},
+	"rec709":     {0.2126, 0.7152, 0.0722},
+	"rec2020":    {0.2627, 0.6780, 0.0593},
+	"perceptual": {0.2126, 0.7152, 0.0722},
+}
+
+// lumaSteps is the resolution linear luminance is encoded at, enough that
+// neighbouring steps are less than one gray level apart even in the shadows.
+const lumaSteps = 4096
+
+// lumaTable turns 8-bit channels into a gray value with table lookups and
+// a few additions, whatever the model.
+//
+// rec601 is the classic formula: the weights applied straight to the gamma
+// encoded values, after contrast and brightness per channel. The others
+// weigh linear light, so saturated colors no longer come out darker than
+// they look, and encode the sum back with the sRGB curve, or as CIE L* for
+// perceptual. Contrast, brightness and invert then apply to that luminance
+// and are folded into the encode table.
+type lumaTable struct {
+	linear     bool
+	r, g, b    [256]float64 // rec601: weighted channel after contrast and brightness
+	base, sign float64      // rec601: 0 and 1, or 255 and -1 to invert
+
+	lr, lg, lb [256]uint32 // weighted linear light, summing to under lumaSteps<<4
+	encode     [lumaSteps]uint8
+}
+
+// gammaGray is the rec601 path.
+func (t *lumaTable) gammaGray(c color.RGBA64) uint8 {
+	return uint8(t.base + t.sign*(t.r[c.R>>8]+t.g[c.G>>8]+t.b[c.B>>8]))
+}
+
+// linearGray is the path of every other model. The entries are rounded down,
+// so the sum stays in range and the mask only spares the bounds check.
+func (t *lumaTable) linearGray(c color.RGBA64) uint8 {
+	return t.encode[(t.lr[c.R>>8]+t.lg[c.G>>8]+t.lb[c.B>>8])>>4&(lumaSteps-1)]
+}
+
+func newLumaTable(model string, contrast, brightness float64, invert bool) lumaTable {
+	w := lumaWeights[model]
+	t := lumaTable{linear: model != "rec601", sign: 1}
+	if invert {
+		t.base, t.sign = 255, -1
+	}
+	adjust := func(v float64) float64 {
+		return clamp((v-128)*contrast+128+brightness, 0, 255)
+	}
+
+	if !t.linear {
+		for v := range t.r {
+			a := adjust(float64(v))
+			t.r[v], t.g[v], t.b[v] = w[0]*a, w[1]*a, w[2]*a
+		}
+		return t
+	}
+
+	scale := float64(lumaSteps<<4 - 1)
+	for v := range t.lr {
+		l := srgbToLinear(float64(v) / 255)
+		t.lr[v] = uint32(w[0] * l * scale)
+		t.lg[v] = uint32(w[1] * l * scale)
+		t.lb[v] = uint32(w[2] * l * scale)
+	}
+	for i := range t.encode {
+		y := (float64(i) + 0.5) / lumaSteps
+		var gray float64
+		if model == "perceptual" {
+			gray = cieLightness(y) / 100 * 255
+		} else {
+			gray = linearToSRGB(y) * 255
+		}
+		gray = adjust(gray)
+		if invert {
+			gray = 255 - gray
+		}
+		t.encode[i] = uint8(math.Round(gray))
+	}
+	return t
+}
+
+// srgbToLinear decodes an sRGB value in 0-1 to linear light.
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB encodes linear light in 0-1 with the sRGB curve.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// cieLightness returns the CIE L* (0-100) of a relative luminance in 0-1.
+func cieLightness(y float64) float64 {
+	const epsilon = 216.0 / 24389
+	if y <= epsilon {
+		return y * 24389 / 27
+	}
+	return 116*math.Cbrt(y) - 16
+}
+
+// grayLuminance is the inverse of the encoding of a linear model: the
+// relative luminance in 0-1 that a gray value in 0-1 stands for.
+func grayLuminance(model string, gray float64) float64 {
+	if model != "perceptual" {
+		return srgbToLinear(gray)
+	}
+	l := gray * 100
+	if l <= 8 {
+		return l * 27 / 24389
+	}
+	return math.Pow((l+16)/116, 3)
+}
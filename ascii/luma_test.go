@@ -0,0 +1,52 @@
+package ascii
+
+import (
+	"image/color"
+	"testing"
+)
+
+// lumaGray runs one 8-bit color through a luma table.
+func lumaGray(t *lumaTable, r, g, b uint8) uint8 {
+	c := color.RGBA64{uint16(r) * 0x101, uint16(g) * 0x101, uint16(b) * 0x101, 0xffff}
+	if t.linear {
+		return t.linearGray(c)
+	}
+	return t.gammaGray(c)
+}
+
+func TestLumaKeepsNeutralGrays(t *testing.T) {
+	// The weights sum to one, so linear light decodes and encodes back to
+	// the same gray
+	for _, model := range []string{"rec709", "rec2020"} {
+		table := newLumaTable(model, 1, 0, false)
+		for v := 0; v < 256; v++ {
+			if got := lumaGray(&table, uint8(v), uint8(v), uint8(v)); got != uint8(v) {
+				t.Errorf("%s: gray %d came out as %d", model, v, got)
+			}
+		}
+	}
+}
+
+func TestLumaWeighsLinearLight(t *testing.T) {
+	tests := []struct {
+		name    string
+		r, g, b uint8
+	}{
+		{"blue", 0, 0, 255},
+		{"red", 255, 0, 0},
+		{"dark blue", 0, 0, 128},
+	}
+	tables := map[string]*lumaTable{}
+	for _, model := range []string{"rec601", "rec709", "rec2020"} {
+		table := newLumaTable(model, 1, 0, false)
+		tables[model] = &table
+	}
+	for _, tt := range tests {
+		gamma := lumaGray(tables["rec601"], tt.r, tt.g, tt.b)
+		for _, model := range []string{"rec709", "rec2020"} {
+			if linear := lumaGray(tables[model], tt.r, tt.g, tt.b); linear <= gamma {
+				t.Errorf("%s: %s gives %d, no brighter than %d under rec601", tt.name, model, linear, gamma)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ascii
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// bandsPerWorker splits the rows into more bands than workers, so a worker
+// that drew an expensive band doesn't hold everyone else up at the end.
+const bandsPerWorker = 4
+
+// workers returns how many goroutines a conversion may use.
+func (cv *converter) workers() int {
+	if cv.opts.Jobs > 0 {
+		return cv.opts.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// parallelRows calls fn for consecutive bands of rows [start, end) covering
+// 0..rows, spread over the configured number of workers. Bands are disjoint,
+// so fn may write its rows of a shared output without locking. Cancellation
+// is checked before every band. While GIF frames render side by side, each
+// frame only gets its share of the workers.
+func (cv *converter) parallelRows(ctx context.Context, rows int, fn func(start, end int)) error {
+	jobs := cv.workers()
+	if cv.rowJobs > 0 {
+		jobs = cv.rowJobs
+	}
+	if jobs > rows {
+		jobs = rows
+	}
+	if jobs <= 1 {
+		for y := 0; y < rows; y++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			fn(y, y+1)
+		}
+		return nil
+	}
+
+	bandSize := (rows + jobs*bandsPerWorker - 1) / (jobs * bandsPerWorker)
+	bands := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range bands {
+				end := start + bandSize
+				if end > rows {
+					end = rows
+				}
+				fn(start, end)
+			}
+		}()
+	}
+
+	var err error
+	for start := 0; start < rows; start += bandSize {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		bands <- start
+	}
+	close(bands)
+	wg.Wait()
+	return err
+}
+
+// rowProgress reports finished rows to the converter's row callback from
+// any worker. Calls are serialized and done only ever grows.
+type rowProgress struct {
+	mu    sync.Mutex
+	cv    *converter
+	done  int
+	total int
+}
+
+func (cv *converter) newRowProgress(total int) *rowProgress {
+	return &rowProgress{cv: cv, total: total}
+}
+
+func (p *rowProgress) add(rows int) {
+	if p.cv.onRow == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += rows
+	p.cv.onRow(p.done, p.total)
+}
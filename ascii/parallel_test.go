@@ -0,0 +1,124 @@
+package ascii
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// busyTracker records how many calls are running at the same time.
+type busyTracker struct {
+	active, peak atomic.Int64
+}
+
+func (b *busyTracker) enter() {
+	n := b.active.Add(1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	b.active.Add(-1)
+}
+
+func TestParallelRowsCoversEveryRow(t *testing.T) {
+	for _, jobs := range []int{1, 3, 16} {
+		opts := DefaultOptions()
+		opts.Jobs = jobs
+		cv, err := newConverter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var mu sync.Mutex
+		seen := make([]int, 37)
+		err = cv.parallelRows(context.Background(), len(seen), func(start, end int) {
+			mu.Lock()
+			defer mu.Unlock()
+			for y := start; y < end; y++ {
+				seen[y]++
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y, n := range seen {
+			if n != 1 {
+				t.Fatalf("jobs %d: row %d visited %d times", jobs, y, n)
+			}
+		}
+	}
+}
+
+func TestParallelRowsUsesRowJobs(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Jobs = 8
+	cv, err := newConverter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv.rowJobs = 2
+
+	var busy busyTracker
+	err = cv.parallelRows(context.Background(), 64, func(start, end int) { busy.enter() })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak := busy.peak.Load(); peak > 2 {
+		t.Errorf("%d bands ran at once, want at most 2", peak)
+	}
+}
+
+func TestStreamGIFSharesWorkers(t *testing.T) {
+	g := &gif.GIF{}
+	for i := 0; i < 3; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 16, 16), palette.Plan9)
+		frame.Set(i, i, color.White)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 5)
+	}
+	g.Config = image.Config{Width: 16, Height: 16}
+
+	tests := []struct {
+		jobs, rowJobs int
+	}{
+		{1, 1},
+		{2, 1},
+		{3, 1},
+		{8, 2},
+		{16, 5},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Jobs = tt.jobs
+		opts.Width = 8
+		cv, err := newConverter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames := 0
+		err = cv.streamGIF(context.Background(), g, NewCompositor(g), func(i int, frame *Frame) error {
+			if i != frames {
+				t.Errorf("frame %d arrived as number %d", i, frames)
+			}
+			frames++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if frames != len(g.Image) {
+			t.Errorf("jobs %d: got %d frames, want %d", tt.jobs, frames, len(g.Image))
+		}
+		if cv.rowJobs != tt.rowJobs {
+			t.Errorf("jobs %d over %d frames: %d row workers per frame, want %d", tt.jobs, len(g.Image), cv.rowJobs, tt.rowJobs)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ascii
+
+import (
+	"context"
+	"image"
+	"image/gif"
+	"sync"
+	"time"
+)
+
+// gifJob is one frame moving through the pipeline. done is closed once frame
+// or err is set.
+type gifJob struct {
+	index int
+	img   *image.RGBA
+	frame *Frame
+	err   error
+	done  chan struct{}
+}
+
+// streamGIF renders the frames of g on the worker pool and calls fn with each
+// one in display order. Compositing has to stay sequential, every canvas
+// builds on the previous one, so a single producer composites frames in order
+// and queues them for both the renderers and the in-order consumer. The
+// queue length bounds how far rendering may run ahead of fn, and with it how
+// many full-size canvases and rendered frames are alive at once.
+func (cv *converter) streamGIF(ctx context.Context, g *gif.GIF, compositor *Compositor, fn func(i int, frame *Frame) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	// Frames render side by side, so the workers are split between them
+	// instead of every frame spreading its rows over all of them, which
+	// would start workers squared goroutines
+	workers := min(cv.workers(), max(len(g.Image), 1))
+	cv.rowJobs = max(cv.workers()/workers, 1)
+	jobs := make(chan *gifJob)
+	pending := make(chan *gifJob, workers*2)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(pending)
+		for i := range g.Image {
+			job := &gifJob{index: i, img: compositor.Next(), done: make(chan struct{})}
+			select {
+			case pending <- job:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.frame, job.err = cv.render(ctx, job.img)
+				if job.err == nil && job.index < len(g.Delay) {
+					job.frame.Delay = time.Duration(g.Delay[job.index]) * 10 * time.Millisecond
+				}
+				job.img = nil
+				close(job.done)
+			}
+		}()
+	}
+
+	for job := range pending {
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if job.err != nil {
+			return job.err
+		}
+		if err := fn(job.index, job.frame); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,72 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+)
+
+// pixelReader returns the premultiplied 16-bit color at absolute image
+// coordinates (x, y), which must lie inside the bounds.
+type pixelReader func(x, y int) rgbaf
+
+// newPixelReader picks a reader for img. The common decoder outputs are read
+// straight from their pixel buffers, which skips the color.Color allocation
+// and model conversion behind every img.At call. Anything else falls back to
+// At, with the same result.
+func newPixelReader(img image.Image) pixelReader {
+	switch src := img.(type) {
+	case *image.RGBA:
+		return func(x, y int) rgbaf {
+			i := src.PixOffset(x, y)
+			p := src.Pix[i : i+4 : i+4]
+			return rgbaf{
+				float64(uint32(p[0]) * 0x101),
+				float64(uint32(p[1]) * 0x101),
+				float64(uint32(p[2]) * 0x101),
+				float64(uint32(p[3]) * 0x101),
+			}
+		}
+	case *image.NRGBA:
+		return func(x, y int) rgbaf {
+			i := src.PixOffset(x, y)
+			p := src.Pix[i : i+4 : i+4]
+			r, g, b, a := color.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}.RGBA()
+			return rgbaf{float64(r), float64(g), float64(b), float64(a)}
+		}
+	case *image.YCbCr:
+		return func(x, y int) rgbaf {
+			// Concrete YCbCr.RGBA keeps the full 16-bit precision of At
+			yi, ci := src.YOffset(x, y), src.COffset(x, y)
+			r, g, b, _ := color.YCbCr{Y: src.Y[yi], Cb: src.Cb[ci], Cr: src.Cr[ci]}.RGBA()
+			return rgbaf{float64(r), float64(g), float64(b), 0xffff}
+		}
+	case *image.Paletted:
+		// Convert the palette once instead of once per pixel
+		palette := make([]rgbaf, len(src.Palette))
+		for i, c := range src.Palette {
+			palette[i] = toRGBAF(c)
+		}
+		return func(x, y int) rgbaf {
+			idx := int(src.Pix[src.PixOffset(x, y)])
+			if idx >= len(palette) {
+				// Transparent rather than the panic Paletted.At would give
+				return rgbaf{}
+			}
+			return palette[idx]
+		}
+	case *image.Gray:
+		return func(x, y int) rgbaf {
+			v := float64(uint32(src.Pix[src.PixOffset(x, y)]) * 0x101)
+			return rgbaf{v, v, v, 0xffff}
+		}
+	}
+
+	return func(x, y int) rgbaf {
+		return toRGBAF(img.At(x, y))
+	}
+}
+
+func toRGBAF(c color.Color) rgbaf {
+	r, g, b, a := c.RGBA()
+	return rgbaf{float64(r), float64(g), float64(b), float64(a)}
+}
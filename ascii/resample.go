@@ -0,0 +1,238 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// resampleFilter is a separable reconstruction kernel. Support is the kernel
+// radius in source pixels at a scale of 1, it widens when downsampling so each
+// cell sees the whole source area it covers.
+type resampleFilter struct {
+	name    string
+	support float64
+	kernel  func(x float64) float64
+}
+
+var (
+	boxFilter = &resampleFilter{
+		name:    "box",
+		support: 0.5,
+		kernel: func(x float64) float64 {
+			if x >= -0.5 && x < 0.5 {
+				return 1
+			}
+			return 0
+		},
+	}
+	bilinearFilter = &resampleFilter{
+		name:    "bilinear",
+		support: 1,
+		kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return 1 - x
+			}
+			return 0
+		},
+	}
+	lanczosFilter = &resampleFilter{
+		name:    "lanczos",
+		support: 3,
+		kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1e-9 {
+				return 1
+			}
+			if x >= 3 {
+				return 0
+			}
+			px := math.Pi * x
+			return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+		},
+	}
+)
+
+// qualityLevels are the accepted --quality values, presets then filter names.
+var qualityLevels = []string{"fast", "normal", "high", "nearest", "box", "bilinear", "lanczos"}
+
+// QualityLevels returns the resampling qualities a conversion accepts.
+func QualityLevels() []string {
+	return append([]string(nil), qualityLevels...)
+}
+
+// filterForQuality maps --quality onto a filter. A nil filter means plain
+// nearest-neighbour point sampling.
+func filterForQuality(quality string) (*resampleFilter, error) {
+	switch quality {
+	case "fast", "nearest":
+		return nil, nil
+	case "normal", "box", "":
+		return boxFilter, nil
+	case "bilinear":
+		return bilinearFilter, nil
+	case "high", "lanczos":
+		return lanczosFilter, nil
+	}
+	return nil, fmt.Errorf("invalid quality: %s", quality)
+}
+
+// contribution lists the source pixels, and their normalized weights, that
+// make up one output coordinate.
+type contribution struct {
+	start   int
+	weights []float64
+}
+
+func computeContributions(src, dst int, filter *resampleFilter) []contribution {
+	scale := float64(src) / float64(dst)
+	filterScale := math.Max(scale, 1)
+	radius := filter.support * filterScale
+
+	contribs := make([]contribution, dst)
+	for i := range contribs {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - radius))
+		end := int(math.Floor(center + radius))
+
+		weights := make([]float64, 0, end-start+1)
+		sum := 0.0
+		for j := start; j <= end; j++ {
+			w := filter.kernel((float64(j) - center) / filterScale)
+			weights = append(weights, w)
+			sum += w
+		}
+
+		if sum == 0 {
+			// Kernel fell between samples, use the closest one
+			nearest := int(math.Round(center))
+			contribs[i] = contribution{start: nearest, weights: []float64{1}}
+			continue
+		}
+		for j := range weights {
+			weights[j] /= sum
+		}
+		contribs[i] = contribution{start: start, weights: weights}
+	}
+	return contribs
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// rgbaf is a premultiplied color with 16-bit range channels.
+type rgbaf struct {
+	r, g, b, a float64
+}
+
+func (c rgbaf) toRGBA64() color.RGBA64 {
+	a := clamp(c.a, 0, 0xffff)
+	return color.RGBA64{
+		R: uint16(clamp(c.r, 0, a) + 0.5),
+		G: uint16(clamp(c.g, 0, a) + 0.5),
+		B: uint16(clamp(c.b, 0, a) + 0.5),
+		A: uint16(a + 0.5),
+	}
+}
+
+// resample reduces the source image to a dstW x dstH grid of colors using the
+// configured filter. Horizontal and vertical passes are done separately, so
+// every source pixel is only read once, and both passes are split into row
+// bands over the worker pool. Coordinates are relative to Bounds().Min,
+// images don't have to start at the origin.
+func (cv *converter) resample(ctx context.Context, img image.Image, dstW, dstH int) ([]color.RGBA64, error) {
+	if dstW < 0 || dstH < 0 {
+		return nil, fmt.Errorf("invalid output size %dx%d", dstW, dstH)
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	out := make([]color.RGBA64, dstW*dstH)
+	if width <= 0 || height <= 0 {
+		return out, nil
+	}
+	at := newPixelReader(img)
+
+	if cv.filter == nil {
+		err := cv.parallelRows(ctx, dstH, func(start, end int) {
+			for y := start; y < end; y++ {
+				srcY := clampIndex(y*height/dstH, height)
+				for x := 0; x < dstW; x++ {
+					srcX := clampIndex(x*width/dstW, width)
+					out[y*dstW+x] = at(bounds.Min.X+srcX, bounds.Min.Y+srcY).toRGBA64()
+				}
+			}
+		})
+		return out, err
+	}
+
+	xContribs := computeContributions(width, dstW, cv.filter)
+	yContribs := computeContributions(height, dstH, cv.filter)
+
+	// Only the source rows some output row depends on are filtered
+	var srcRows []int
+	horizontal := make([][]rgbaf, height)
+	for _, yc := range yContribs {
+		for k := range yc.weights {
+			srcY := clampIndex(yc.start+k, height)
+			if horizontal[srcY] == nil {
+				horizontal[srcY] = make([]rgbaf, dstW)
+				srcRows = append(srcRows, srcY)
+			}
+		}
+	}
+
+	// Horizontal pass, one source row at a time
+	err := cv.parallelRows(ctx, len(srcRows), func(start, end int) {
+		rowBuf := make([]rgbaf, width)
+		for _, srcY := range srcRows[start:end] {
+			for x := 0; x < width; x++ {
+				rowBuf[x] = at(bounds.Min.X+x, bounds.Min.Y+srcY)
+			}
+
+			row := horizontal[srcY]
+			for x, xc := range xContribs {
+				var sum rgbaf
+				for k, w := range xc.weights {
+					p := rowBuf[clampIndex(xc.start+k, width)]
+					sum.r += p.r * w
+					sum.g += p.g * w
+					sum.b += p.b * w
+					sum.a += p.a * w
+				}
+				row[x] = sum
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Vertical pass
+	err = cv.parallelRows(ctx, dstH, func(start, end int) {
+		for y := start; y < end; y++ {
+			yc := yContribs[y]
+			for x := 0; x < dstW; x++ {
+				var sum rgbaf
+				for k, w := range yc.weights {
+					p := horizontal[clampIndex(yc.start+k, height)][x]
+					sum.r += p.r * w
+					sum.g += p.g * w
+					sum.b += p.b * w
+					sum.a += p.a * w
+				}
+				out[y*dstW+x] = sum.toRGBA64()
+			}
+		}
+	})
+	return out, err
+}
@@ -0,0 +1,94 @@
+package ascii
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestContributionsSumToOne(t *testing.T) {
+	sizes := []struct{ src, dst int }{
+		{1, 1}, {1, 5}, {3, 3}, {7, 100}, {100, 7}, {640, 80}, {81, 80},
+	}
+	for _, filter := range []*resampleFilter{boxFilter, bilinearFilter, lanczosFilter} {
+		for _, s := range sizes {
+			contribs := computeContributions(s.src, s.dst, filter)
+			if len(contribs) != s.dst {
+				t.Fatalf("%s %d->%d: %d contributions", filter.name, s.src, s.dst, len(contribs))
+			}
+			for i, c := range contribs {
+				sum := 0.0
+				for _, w := range c.weights {
+					sum += w
+				}
+				if math.Abs(sum-1) > 1e-9 {
+					t.Errorf("%s %d->%d: weights of %d sum to %v", filter.name, s.src, s.dst, i, sum)
+				}
+			}
+		}
+	}
+}
+
+// resampleWith runs img through a converter at the given quality.
+func resampleWith(t *testing.T, quality string, img image.Image, w, h int) []color.RGBA64 {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Quality = quality
+	cv, err := newConverter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := cv.resample(context.Background(), img, w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestBoxAveragesChecker(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.SetGray(0, 0, color.Gray{255})
+	img.SetGray(1, 1, color.Gray{255})
+
+	got := resampleWith(t, "box", img, 1, 1)[0]
+	for _, v := range []uint16{got.R, got.G, got.B} {
+		if v < 0x7fff || v > 0x8000 {
+			t.Errorf("2x2 checker averaged to %v, want mid-gray", got)
+			break
+		}
+	}
+	if got.A != 0xffff {
+		t.Errorf("alpha %d, want opaque", got.A)
+	}
+}
+
+func TestFastMatchesNearest(t *testing.T) {
+	// Every pixel distinct, on bounds away from the origin
+	img := image.NewRGBA(image.Rect(5, 3, 42, 26))
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 6), uint8(y * 10), uint8(x ^ y), 255})
+		}
+	}
+
+	for _, size := range []struct{ w, h int }{{10, 7}, {37, 23}, {80, 50}} {
+		fast := resampleWith(t, "fast", img, size.w, size.h)
+		nearest := resampleWith(t, "nearest", img, size.w, size.h)
+		for y := 0; y < size.h; y++ {
+			for x := 0; x < size.w; x++ {
+				src := image.Pt(b.Min.X+x*b.Dx()/size.w, b.Min.Y+y*b.Dy()/size.h)
+				want := color.RGBA64Model.Convert(img.At(src.X, src.Y)).(color.RGBA64)
+				i := y*size.w + x
+				if fast[i] != want {
+					t.Errorf("%dx%d cell %d,%d = %v, want %v from %v", size.w, size.h, x, y, fast[i], want, src)
+				}
+				if nearest[i] != fast[i] {
+					t.Errorf("%dx%d cell %d,%d: nearest %v, fast %v", size.w, size.h, x, y, nearest[i], fast[i])
+				}
+			}
+		}
+	}
+}
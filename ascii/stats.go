@@ -0,0 +1,100 @@
+package ascii
+
+import (
+	"image/color"
+	"sync"
+)
+
+// colorStats accumulates what a conversion looked like, over every cell of
+// every frame. Frames may render concurrently, so observe locks.
+type colorStats struct {
+	mu        sync.Mutex
+	luma      float64
+	cells     int64
+	histogram map[uint16]*bucket
+}
+
+// bucket collects the colors that fall into one 4-bit-per-channel box.
+type bucket struct {
+	r, g, b int64
+	count   int64
+}
+
+// observe adds the cell colors of a rendered frame. Fully transparent cells
+// show nothing and are skipped.
+func (s *colorStats) observe(frame *Frame) {
+	var luma float64
+	var cells int64
+	local := make(map[uint16]*bucket)
+	for _, cl := range frame.Cells {
+		c := cl.Color
+		if c.A == 0 {
+			continue
+		}
+		luma += 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+		cells++
+
+		key := uint16(c.R>>4)<<8 | uint16(c.G>>4)<<4 | uint16(c.B>>4)
+		bk := local[key]
+		if bk == nil {
+			bk = &bucket{}
+			local[key] = bk
+		}
+		bk.r += int64(c.R)
+		bk.g += int64(c.G)
+		bk.b += int64(c.B)
+		bk.count++
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.histogram == nil {
+		s.histogram = make(map[uint16]*bucket)
+	}
+	s.luma += luma
+	s.cells += cells
+	for key, bk := range local {
+		total := s.histogram[key]
+		if total == nil {
+			total = &bucket{}
+			s.histogram[key] = total
+		}
+		total.r += bk.r
+		total.g += bk.g
+		total.b += bk.b
+		total.count += bk.count
+	}
+}
+
+// brightness returns the average luminance of the observed cells, 0-255.
+func (s *colorStats) brightness() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cells == 0 {
+		return 0
+	}
+	return s.luma / float64(s.cells)
+}
+
+// dominant returns the average color of the most populated box. Ties go to
+// the lower key, so the result doesn't depend on map order.
+func (s *colorStats) dominant() color.RGBA {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var best *bucket
+	var bestKey uint16
+	for key, bk := range s.histogram {
+		if best == nil || bk.count > best.count || (bk.count == best.count && key < bestKey) {
+			best, bestKey = bk, key
+		}
+	}
+	if best == nil {
+		return color.RGBA{}
+	}
+	return color.RGBA{
+		R: uint8(best.r / best.count),
+		G: uint8(best.g / best.count),
+		B: uint8(best.b / best.count),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,246 @@
+package ascii
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// toneModes are the automatic tone mappings applied before glyph selection.
+var toneModes = []string{"none", "autolevels", "equalize", "clahe"}
+
+// ToneModes returns the tone mappings ParseTone accepts.
+func ToneModes() []string {
+	return append([]string(nil), toneModes...)
+}
+
+// Tone is a parsed tone mapping. Clip is the percentage of values clipped at
+// each end for autolevels, and the clip limit as a multiple of the average
+// histogram bin for clahe.
+type Tone struct {
+	Mode string
+	Clip float64
+}
+
+// ParseTone parses a tone mapping given as a mode with an optional clip
+// value, like "autolevels", "autolevels:0.5" or "clahe:3".
+func ParseTone(spec string) (Tone, error) {
+	mode, clip, hasClip := strings.Cut(spec, ":")
+	tone := Tone{Mode: mode}
+	switch mode {
+	case "", "none", "off":
+		tone.Mode = "none"
+	case "autolevels", "levels", "auto":
+		tone.Mode, tone.Clip = "autolevels", 1
+	case "equalize", "equalise", "histogram":
+		tone.Mode = "equalize"
+	case "clahe":
+		tone.Clip = 2
+	default:
+		return Tone{}, fmt.Errorf("invalid tone mapping: %s", spec)
+	}
+
+	if hasClip {
+		v, err := strconv.ParseFloat(clip, 64)
+		valid := err == nil
+		switch tone.Mode {
+		case "autolevels":
+			valid = valid && v >= 0 && v < 50
+		case "clahe":
+			valid = valid && v >= 1
+		default:
+			valid = false
+		}
+		if !valid {
+			return Tone{}, fmt.Errorf("invalid tone mapping: %s", spec)
+		}
+		tone.Clip = v
+	}
+	return tone, nil
+}
+
+// applyTone remaps the gray values of a w x h grid in place. Transparent
+// samples are left out of the histograms and keep their value.
+func (cv *converter) applyTone(grays []uint8, samples []color.RGBA64, w, h int) {
+	opaque := func(i int) bool { return !transparent(samples[i]) }
+	switch cv.tone.Mode {
+	case "autolevels":
+		remap(grays, opaque, autoLevels(grayHistogram(grays, opaque, 0, 0, w, h, w), cv.tone.Clip))
+	case "equalize":
+		remap(grays, opaque, equalize(grayHistogram(grays, opaque, 0, 0, w, h, w), 0))
+	case "clahe":
+		clahe(grays, opaque, w, h, cv.tone.Clip)
+	}
+}
+
+// grayHistogram counts the values of the opaque samples in the rectangle
+// x0,y0 to x1,y1 of a grid with the given stride.
+func grayHistogram(grays []uint8, opaque func(int) bool, x0, y0, x1, y1, stride int) *[256]int {
+	var hist [256]int
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if i := y*stride + x; opaque(i) {
+				hist[grays[i]]++
+			}
+		}
+	}
+	return &hist
+}
+
+func remap(grays []uint8, opaque func(int) bool, lut *[256]uint8) {
+	for i, v := range grays {
+		if opaque(i) {
+			grays[i] = lut[v]
+		}
+	}
+}
+
+func identityLUT() *[256]uint8 {
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = uint8(v)
+	}
+	return &lut
+}
+
+// autoLevels stretches the range between the clip and 100-clip percentiles
+// to the full 0-255, so a few stray pixels don't hold the range open.
+func autoLevels(hist *[256]int, clip float64) *[256]uint8 {
+	total := 0
+	for _, n := range hist {
+		total += n
+	}
+	skip := int(float64(total) * clip / 100)
+
+	low, high := 0, 255
+	for seen := 0; low < 255; low++ {
+		if seen += hist[low]; seen > skip {
+			break
+		}
+	}
+	for seen := 0; high > 0; high-- {
+		if seen += hist[high]; seen > skip {
+			break
+		}
+	}
+	if high <= low {
+		// Flat image, nothing to stretch
+		return identityLUT()
+	}
+
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = uint8(clamp(float64(v-low)*255/float64(high-low)+0.5, 0, 255))
+	}
+	return &lut
+}
+
+// equalize maps values through the cumulative histogram, so they spread
+// evenly over 0-255. With a clip limit above 0, bins are first cut to limit
+// times the average bin and the excess is spread over all bins, which bounds
+// how much any one value can be stretched.
+func equalize(hist *[256]int, limit float64) *[256]uint8 {
+	total := 0
+	for _, n := range hist {
+		total += n
+	}
+	if total == 0 {
+		return identityLUT()
+	}
+
+	bins := make([]float64, 256)
+	for v, n := range hist {
+		bins[v] = float64(n)
+	}
+	if limit > 0 {
+		ceiling := limit * float64(total) / 256
+		excess := 0.0
+		for v, n := range bins {
+			if n > ceiling {
+				excess += n - ceiling
+				bins[v] = ceiling
+			}
+		}
+		for v := range bins {
+			bins[v] += excess / 256
+		}
+	}
+
+	// The darkest value present stays black instead of starting at its own
+	// share of the histogram
+	first := 0.0
+	for _, n := range bins {
+		if n > 0 {
+			first = n
+			break
+		}
+	}
+
+	var lut [256]uint8
+	cdf := 0.0
+	for v, n := range bins {
+		cdf += n
+		if float64(total) <= first {
+			lut[v] = uint8(v)
+			continue
+		}
+		lut[v] = uint8(clamp((cdf-first)*255/(float64(total)-first)+0.5, 0, 255))
+	}
+	return &lut
+}
+
+// claheTile is the smallest tile CLAHE uses, in samples. Smaller tiles have
+// too few samples for a meaningful histogram.
+const claheTile = 8
+
+// clahe is contrast limited adaptive histogram equalization: the grid is
+// split into up to 8x8 tiles, each is equalized with clipping on its own, and
+// every value is blended from the mappings of the four nearest tile centers so
+// no tile edges show.
+func clahe(grays []uint8, opaque func(int) bool, w, h int, limit float64) {
+	tilesX := min(max(w/claheTile, 1), 8)
+	tilesY := min(max(h/claheTile, 1), 8)
+
+	luts := make([]*[256]uint8, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			hist := grayHistogram(grays, opaque, tx*w/tilesX, ty*h/tilesY, (tx+1)*w/tilesX, (ty+1)*h/tilesY, w)
+			luts[ty*tilesX+tx] = equalize(hist, limit)
+		}
+	}
+
+	// Position of a coordinate between tile centers: the two tiles and how
+	// far along from the first to the second
+	between := func(p, size, tiles int) (int, int, float64) {
+		f := (float64(p)+0.5)*float64(tiles)/float64(size) - 0.5
+		if f <= 0 {
+			return 0, 0, 0
+		}
+		if f >= float64(tiles-1) {
+			return tiles - 1, tiles - 1, 0
+		}
+		t0 := int(f)
+		return t0, t0 + 1, f - float64(t0)
+	}
+
+	for y := 0; y < h; y++ {
+		ty0, ty1, fy := between(y, h, tilesY)
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if !opaque(i) {
+				continue
+			}
+			tx0, tx1, fx := between(x, w, tilesX)
+			v := grays[i]
+			// Blended as a step from the first value, which is exact when the
+			// neighbouring tiles agree
+			lerp := func(a, b uint8, f float64) float64 {
+				return float64(a) + f*(float64(b)-float64(a))
+			}
+			top := lerp(luts[ty0*tilesX+tx0][v], luts[ty0*tilesX+tx1][v], fx)
+			bottom := lerp(luts[ty1*tilesX+tx0][v], luts[ty1*tilesX+tx1][v], fx)
+			grays[i] = uint8(top + fy*(bottom-top) + 0.5)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package ascii
+
+import (
+	"image/color"
+	"testing"
+)
+
+// toneGrid runs a tone mapping over a copy of gray with every sample opaque.
+func toneGrid(t *testing.T, spec string, gray []uint8, width, height int) []uint8 {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Tone = spec
+	cv, err := newConverter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]color.RGBA64, len(gray))
+	for i := range samples {
+		samples[i].A = 0xffff
+	}
+	out := append([]uint8(nil), gray...)
+	cv.applyTone(out, samples, width, height)
+	return out
+}
+
+func grayRange(gray []uint8) (uint8, uint8) {
+	low, high := gray[0], gray[0]
+	for _, v := range gray {
+		low, high = min(low, v), max(high, v)
+	}
+	return low, high
+}
+
+func TestParseTone(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Tone
+		ok   bool
+	}{
+		{"", Tone{Mode: "none"}, true},
+		{"off", Tone{Mode: "none"}, true},
+		{"autolevels", Tone{"autolevels", 1}, true},
+		{"levels:0.5", Tone{"autolevels", 0.5}, true},
+		{"autolevels:0", Tone{"autolevels", 0}, true},
+		{"autolevels:50", Tone{}, false},
+		{"autolevels:-1", Tone{}, false},
+		{"equalise", Tone{Mode: "equalize"}, true},
+		{"equalize:2", Tone{}, false},
+		{"clahe", Tone{"clahe", 2}, true},
+		{"clahe:3.5", Tone{"clahe", 3.5}, true},
+		{"clahe:0.5", Tone{}, false},
+		{"clahe:x", Tone{}, false},
+		{"none:1", Tone{}, false},
+		{"sharpen", Tone{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseTone(tt.spec)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseTone(%q) = %+v, %v, want %+v, ok %v", tt.spec, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestToneConstantImage(t *testing.T) {
+	const width, height = 64, 64
+	tests := []struct {
+		spec      string
+		tolerance int
+	}{
+		{"autolevels", 0},
+		{"equalize", 0},
+		// A clip limit of 1 flattens every tile histogram to about even,
+		// close to the identity. Higher limits may move the value further.
+		{"clahe:1", 1},
+		{"clahe", 2},
+		{"clahe:8", 16},
+	}
+	for _, tt := range tests {
+		for _, v := range []uint8{0, 30, 100, 200, 255} {
+			out := toneGrid(t, tt.spec, constantGrid(v, width*height), width, height)
+			low, high := grayRange(out)
+			if low != high {
+				t.Errorf("%s on constant %d: output ranges %d-%d, want flat", tt.spec, v, low, high)
+			}
+			if d := int(low) - int(v); d < -tt.tolerance || d > tt.tolerance {
+				t.Errorf("%s on constant %d: got %d", tt.spec, v, low)
+			}
+		}
+	}
+}
+
+func TestClaheGradient(t *testing.T) {
+	// Bigger than 8 tiles of claheTile, so tiles and blending are both at work
+	const width, height = 96, 80
+	prev := 0
+	for _, spec := range []string{"clahe:1", "clahe", "clahe:4", "clahe:16"} {
+		out := toneGrid(t, spec, gradientGrid(width, height), width, height)
+		for y := 1; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if out[y*width+x] != out[x] {
+					t.Fatalf("%s: row %d differs from row 0 at column %d", spec, y, x)
+				}
+			}
+		}
+		if low, high := grayRange(out); low > 8 || high < 247 {
+			t.Errorf("%s: ramp covers %d-%d, want about 0-255", spec, low, high)
+		}
+
+		// Each tile stretches its part of the ramp, further the higher the
+		// limit, and blending may fold it back where neighbouring tiles
+		// disagree. With moderate limits that stays within a level or two
+		// and there are no seams.
+		down, up := 0, 0
+		for x := 1; x < width; x++ {
+			step := int(out[x]) - int(out[x-1])
+			down, up = min(down, step), max(up, step)
+		}
+		if up <= prev {
+			t.Errorf("%s: steepest step %d, no steeper than with a lower limit (%d)", spec, up, prev)
+		}
+		prev = up
+		if spec != "clahe:16" && (down < -2 || up > 8) {
+			t.Errorf("%s: steps between columns range %d to %d, want -2 to 8", spec, down, up)
+		}
+	}
+}
+
+func TestClaheClipLimit(t *testing.T) {
+	// A dull ramp over 100-131, stretched further the higher the limit
+	const width, height = 64, 64
+	gray := make([]uint8, width*height)
+	for i := range gray {
+		gray[i] = uint8(100 + i%width/2)
+	}
+
+	prev := 0
+	for _, limit := range []string{"1", "2", "4", "16"} {
+		low, high := grayRange(toneGrid(t, "clahe:"+limit, gray, width, height))
+		spread := int(high) - int(low)
+		if spread <= prev {
+			t.Errorf("clahe:%s spreads the ramp over %d levels, no more than a lower limit (%d)", limit, spread, prev)
+		}
+		prev = spread
+	}
+	if low, high := grayRange(toneGrid(t, "clahe:1", gray, width, height)); low < 90 || high > 141 {
+		t.Errorf("clahe:1 moved the ramp to %d-%d, want it near 100-131", low, high)
+	}
+}
+
+func TestAutoLevels(t *testing.T) {
+	const width, height = 100, 10
+	gray := make([]uint8, width*height)
+	for i := range gray {
+		gray[i] = uint8(50 + i%width)
+	}
+	if low, high := grayRange(toneGrid(t, "autolevels:0", gray, width, height)); low != 0 || high != 255 {
+		t.Errorf("autolevels:0 stretched 50-149 to %d-%d, want 0-255", low, high)
+	}
+
+	// One stray black and white pixel are clipped away by default, and hold
+	// the range open without clipping
+	gray[0], gray[1] = 0, 255
+	out := toneGrid(t, "autolevels", gray, width, height)
+	if out[2] > 5 || out[width-1] < 250 {
+		t.Errorf("autolevels: ramp ends at %d and %d, want about 0 and 255", out[2], out[width-1])
+	}
+	out = toneGrid(t, "autolevels:0", gray, width, height)
+	if out[2] != gray[2] {
+		t.Errorf("autolevels:0 moved %d to %d despite the full range", gray[2], out[2])
+	}
+}
+
+func TestEqualize(t *testing.T) {
+	const width, height = 16, 16
+	gray := make([]uint8, width*height)
+	for i := range gray {
+		gray[i] = 100 + uint8(i%4)*3
+	}
+	out := toneGrid(t, "equalize", gray, width, height)
+	want := map[uint8]uint8{100: 0, 103: 85, 106: 170, 109: 255}
+	for i, v := range gray {
+		if out[i] != want[v] {
+			t.Fatalf("equalize mapped %d to %d, want %d", v, out[i], want[v])
+		}
+	}
+}
+
+func TestApplyToneSkipsTransparent(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Tone = "autolevels:0"
+	cv, err := newConverter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray := []uint8{100, 150, 0, 255}
+	samples := []color.RGBA64{{A: 0xffff}, {A: 0xffff}, {}, {}}
+	cv.applyTone(gray, samples, 4, 1)
+	if want := []uint8{0, 255, 0, 255}; string(gray) != string(want) {
+		t.Errorf("got %v, want %v", gray, want)
+	}
+}
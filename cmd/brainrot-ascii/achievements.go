@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zsombyy/ASCII-Converter-Genz-Edition/ascii"
+)
+
+// Profile is the lifetime record kept in the state directory, shared by
+// every invocation on the machine.
+type Profile struct {
+	Conversions int64                `json:"conversions"`
+	Pixels      int64                `json:"pixels"`
+	Frames      int64                `json:"frames"`
+	Charsets    map[string]int64     `json:"charsets"`
+	Levels      map[string]int64     `json:"levels"`
+	Streak      int                  `json:"streak"`
+	BestStreak  int                  `json:"best_streak"`
+	LastDay     string               `json:"last_day"`
+	FirstRun    time.Time            `json:"first_run"`
+	LastRun     time.Time            `json:"last_run"`
+	Unlocked    map[string]time.Time `json:"unlocked"`
+}
+
+// achievement is one unlockable rule. Progress reports how far the profile
+// is towards target; it is unlocked once progress reaches it.
+type achievement struct {
+	id       string
+	name     string
+	desc     string
+	progress func(p *Profile) (current, target int64)
+}
+
+var achievements = []achievement{
+	{"first-steps", "Baby's First ASCII", "Convert your first image",
+		func(p *Profile) (int64, int64) { return p.Conversions, 1 }},
+	{"sigma-grindset", "Sigma Grindset", "Convert 100 images",
+		func(p *Profile) (int64, int64) { return p.Conversions, 100 }},
+	{"pixel-destroyer", "Professional Pixel Destroyer", "Convert a million pixels",
+		func(p *Profile) (int64, int64) { return p.Pixels, 1000000 }},
+	{"pixel-menace", "Certified Pixel Menace", "Convert a hundred million pixels",
+		func(p *Profile) (int64, int64) { return p.Pixels, 100000000 }},
+	{"frame-farmer", "Frame Farmer", "Render 1000 frames",
+		func(p *Profile) (int64, int64) { return p.Frames, 1000 }},
+	{"collector", "Gotta Convert 'Em All", "Use every built-in ASCII set",
+		func(p *Profile) (int64, int64) {
+			names := ascii.CharsetNames()
+			used := int64(0)
+			for _, name := range names {
+				if p.Charsets[name] > 0 {
+					used++
+				}
+			}
+			return used, int64(len(names))
+		}},
+	{"no-grass", "Touch Grass? Never", "Convert something 7 days in a row",
+		func(p *Profile) (int64, int64) { return int64(p.BestStreak), 7 }},
+	{"ceo-of-existence", "CEO of Existence", "Finish a conversion in GIGACHAD mode",
+		func(p *Profile) (int64, int64) { return p.Levels["GIGACHAD"], 1 }},
+}
+
+// stateDir is $XDG_STATE_HOME/brainrot-ascii, ~/.local/state/brainrot-ascii
+// when the variable isn't set.
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, APP_NAME), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", APP_NAME), nil
+}
+
+// loadProfile reads the profile, a missing file is a fresh profile.
+func loadProfile(path string) (*Profile, error) {
+	profile := &Profile{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, profile); err != nil {
+			return nil, fmt.Errorf("corrupt profile %s: %v", path, err)
+		}
+	}
+	if profile.Charsets == nil {
+		profile.Charsets = make(map[string]int64)
+	}
+	if profile.Levels == nil {
+		profile.Levels = make(map[string]int64)
+	}
+	if profile.Unlocked == nil {
+		profile.Unlocked = make(map[string]time.Time)
+	}
+	return profile, nil
+}
+
+// saveProfile replaces the profile atomically, a reader never sees half of it.
+func saveProfile(path string, profile *Profile) error {
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".profile-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// updateProfile runs fn on the profile under the state directory lock and
+// saves the result, so concurrent invocations never lose each other's runs.
+func updateProfile(fn func(p *Profile)) (*Profile, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockFile(filepath.Join(dir, "profile.lock"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot lock profile: %v", err)
+	}
+	defer unlock()
+
+	path := filepath.Join(dir, "profile.json")
+	profile, err := loadProfile(path)
+	if err != nil {
+		return nil, err
+	}
+	fn(profile)
+	return profile, saveProfile(path, profile)
+}
+
+// recordRun adds a finished conversion to the profile and returns the
+// achievements it unlocked, each one exactly once over the profile's life.
+func recordRun(stats *ConversionStats, now time.Time) ([]achievement, error) {
+	var unlocked []achievement
+	_, err := updateProfile(func(p *Profile) {
+		p.Conversions++
+		p.Pixels += stats.PixelCount
+		p.Frames += int64(stats.FrameCount)
+		p.Charsets[stats.Charset]++
+		p.Levels[stats.Level]++
+
+		// Streaks count calendar days in local time
+		today := now.Format("2006-01-02")
+		switch p.LastDay {
+		case today:
+		case now.AddDate(0, 0, -1).Format("2006-01-02"):
+			p.Streak++
+		default:
+			p.Streak = 1
+		}
+		p.LastDay = today
+		if p.Streak > p.BestStreak {
+			p.BestStreak = p.Streak
+		}
+		if p.FirstRun.IsZero() {
+			p.FirstRun = now
+		}
+		p.LastRun = now
+
+		for _, a := range achievements {
+			if _, done := p.Unlocked[a.id]; done {
+				continue
+			}
+			if current, target := a.progress(p); current >= target {
+				p.Unlocked[a.id] = now
+				unlocked = append(unlocked, a)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unlocked, nil
+}
+
+// printAchievements is the achievements subcommand: lifetime stats and the
+// progress on every rule.
+func printAchievements(w io.Writer) error {
+	dir, err := stateDir()
+	if err != nil {
+		return err
+	}
+	profile, err := loadProfile(filepath.Join(dir, "profile.json"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "🏆 ACHIEVEMENTS 🏆\n\n")
+	for _, a := range achievements {
+		current, target := a.progress(profile)
+		if current > target {
+			current = target
+		}
+		if at, done := profile.Unlocked[a.id]; done {
+			fmt.Fprintf(w, "✅ %-30s %s (unlocked %s)\n", a.name, a.desc, at.Format("2006-01-02"))
+			continue
+		}
+		filled := int(current * 10 / target)
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+		fmt.Fprintf(w, "🔒 %-30s %s [%s] %d/%d\n", a.name, a.desc, bar, current, target)
+	}
+
+	fmt.Fprintf(w, "\n📊 LIFETIME STATS 📊\n")
+	fmt.Fprintf(w, "Conversions: %d\n", profile.Conversions)
+	fmt.Fprintf(w, "Pixels: %d\n", profile.Pixels)
+	fmt.Fprintf(w, "Frames: %d\n", profile.Frames)
+	fmt.Fprintf(w, "Day streak: %d (best %d)\n", profile.Streak, profile.BestStreak)
+	if len(profile.Charsets) > 0 {
+		names := make([]string, 0, len(profile.Charsets))
+		for name := range profile.Charsets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(w, "ASCII sets used: %s\n", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// recordAchievements saves the finished run and announces what it unlocked,
+// unless the run is seeded. The profile is a side show: failing to save it never fails the conversion.
+func (ac *ASCIIConverter) recordAchievements() {
+	if ac.config.NoAchievements {
+		return
+	}
+	unlocked, err := recordRun(ac.stats, time.Now())
+	if err != nil {
+		ac.log("Achievements not saved: %v", err)
+		return
+	}
+	// What unlocks depends on the saved profile, not the run, so seeded runs
+	// keep quiet about it to stay reproducible
+	if ac.config.BrainrotLevel == "off" || ac.config.Silent || ac.config.SeedSet {
+		return
+	}
+	for _, a := range unlocked {
+		fmt.Fprintf(ac.chatter, "\n🌟 ACHIEVEMENT UNLOCKED: %s 🌟\n%s\n", a.name, a.desc)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zsombyy/ASCII-Converter-Genz-Edition/ascii"
+)
+
+// inputExtensions are the files a recursive directory walk picks up.
+var inputExtensions = map[string]bool{
+	".jpg":      true,
+	".jpeg":     true,
+	".png":      true,
+	".gif":      true,
+	".bmp":      true,
+	".dib":      true,
+	".pbm":      true,
+	".pgm":      true,
+	".ppm":      true,
+	".pnm":      true,
+	".tga":      true,
+	".qoi":      true,
+	".ff":       true,
+	".farbfeld": true,
+}
+
+// batchInput is one file to convert. Rel is where its output goes under
+// --out-dir, with the source extension still on. Err is set for arguments
+// that turned out to name nothing to convert, they fail in the summary.
+type batchInput struct {
+	path string
+	rel  string
+	err  error
+}
+
+// batchResult is the outcome of converting one batchInput.
+type batchResult struct {
+	input  batchInput
+	output string
+	stats  *ConversionStats
+	err    error
+}
+
+// collectInputs expands the command line arguments into files. Globs are
+// expanded here so they work without a shell, directories are walked when
+// recursive is set. Files found in a directory keep their path below it,
+// files named directly keep their path below the deepest directory holding
+// all of them, and "-" is stdin. The same file named twice is converted once.
+// An argument that names no file is kept as a failed input, only a malformed
+// pattern or an unreadable directory stops the batch.
+func collectInputs(args []string, recursive bool) ([]batchInput, error) {
+	var inputs []batchInput
+	var direct []int
+	seen := make(map[string]bool)
+	add := func(path, rel string) bool {
+		path = filepath.Clean(path)
+		if seen[path] {
+			return false
+		}
+		seen[path] = true
+		inputs = append(inputs, batchInput{path: path, rel: rel})
+		return true
+	}
+	fail := func(arg string, err error) {
+		inputs = append(inputs, batchInput{path: arg, rel: arg, err: err})
+	}
+
+	for _, arg := range args {
+		if arg == "-" {
+			if !seen[arg] {
+				seen[arg] = true
+				inputs = append(inputs, batchInput{path: arg, rel: "stdin"})
+			}
+			continue
+		}
+
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("bad pattern '%s': %v", arg, err)
+			}
+			if len(matches) == 0 {
+				fail(arg, fmt.Errorf("no files match '%s'", arg))
+				continue
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				fail(match, fmt.Errorf("file '%s' not found", match))
+				continue
+			}
+			if !info.IsDir() {
+				if add(match, match) {
+					direct = append(direct, len(inputs)-1)
+				}
+				continue
+			}
+			if !recursive {
+				fail(match, fmt.Errorf("'%s' is a directory, use -r to convert everything in it", match))
+				continue
+			}
+
+			root := match
+			err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() || !inputExtensions[strings.ToLower(filepath.Ext(path))] {
+					return nil
+				}
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				add(path, rel)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("cannot walk '%s': %v", root, err)
+			}
+		}
+	}
+
+	// Directly named files from different directories, like 'a/*.png' and
+	// 'b/*.png', keep the directories that tell them apart
+	abs := make([]string, len(direct))
+	for i, index := range direct {
+		path, err := filepath.Abs(inputs[index].path)
+		if err != nil {
+			path = inputs[index].path
+		}
+		abs[i] = path
+	}
+	if len(abs) > 0 {
+		parent := commonParent(abs)
+		for i, index := range direct {
+			rel, err := filepath.Rel(parent, abs[i])
+			if err != nil {
+				rel = filepath.Base(abs[i])
+			}
+			inputs[index].rel = rel
+		}
+	}
+	return inputs, nil
+}
+
+// commonParent returns the deepest directory that contains every path.
+func commonParent(paths []string) string {
+	parent := filepath.Dir(paths[0])
+	for _, path := range paths[1:] {
+		for {
+			rel, err := filepath.Rel(parent, path)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+			up := filepath.Dir(parent)
+			if up == parent {
+				break
+			}
+			parent = up
+		}
+	}
+	return parent
+}
+
+// batchOutputs maps every input to its file under outDir, the source
+// extension swapped for the one of the format. Inputs that would overwrite
+// each other, like a.png and a.jpg side by side, keep their source extension
+// instead (a.png.txt and a.jpg.txt), and a number is added if even that is
+// taken. Failed inputs get no output.
+func batchOutputs(inputs []batchInput, outDir, format string) []string {
+	ext := ascii.ExtensionForFormat(format)
+	output := func(rel string) string {
+		return filepath.Join(outDir, rel+ext)
+	}
+	trimmed := func(in batchInput) string {
+		return output(strings.TrimSuffix(in.rel, filepath.Ext(in.rel)))
+	}
+
+	claims := make(map[string]int)
+	for _, in := range inputs {
+		if in.err == nil {
+			claims[trimmed(in)]++
+		}
+	}
+
+	outputs := make([]string, len(inputs))
+	taken := make(map[string]bool)
+	for i, in := range inputs {
+		if out := trimmed(in); in.err == nil && claims[out] == 1 {
+			outputs[i] = out
+			taken[out] = true
+		}
+	}
+	for i, in := range inputs {
+		if in.err != nil || outputs[i] != "" {
+			continue
+		}
+		out := output(in.rel)
+		for n := 2; taken[out]; n++ {
+			out = output(fmt.Sprintf("%s-%d", in.rel, n))
+		}
+		outputs[i] = out
+		taken[out] = true
+	}
+	return outputs
+}
+
+// runBatch converts every input into --out-dir, at most --batch-jobs files
+// at a time, then prints a summary table to stdout. Each file gets its own
+// converter, so commentary, stats and achievements are per file, and a share
+// of the --jobs row workers so the files don't each start one per CPU. It
+// reports whether every file converted.
+func (ac *ASCIIConverter) runBatch(inputs []batchInput) bool {
+	outputs := batchOutputs(inputs, ac.config.OutDir, ac.config.Format)
+
+	workers := ac.config.BatchJobs
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	jobs := ac.config.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	jobs = max(jobs/workers, 1)
+	ac.log("Batch: %d files, %d at a time with %d row workers each", len(inputs), workers, jobs)
+
+	results := make([]batchResult, len(inputs))
+	indexes := make(chan int)
+	finished := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = ac.convertOne(i, inputs[i], outputs[i], jobs)
+				finished <- i
+			}
+		}()
+	}
+	go func() {
+		for i := range inputs {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(finished)
+	}()
+
+	// Progress counts files, a bar per file would garble with several running
+	done := 0
+	for range finished {
+		done++
+		ac.progress(done, len(inputs), "Converting files")
+	}
+	ac.stats.EndTime = time.Now()
+
+	return ac.printBatchSummary(results)
+}
+
+// convertOne converts the batch input at index i with a converter of its own
+// and jobs row workers. Its seed is offset by the index, so files don't all
+// draw the same commentary and a seeded batch still repeats exactly.
+func (ac *ASCIIConverter) convertOne(i int, in batchInput, output string, jobs int) batchResult {
+	if in.err != nil {
+		return batchResult{input: in, err: in.err}
+	}
+
+	config := *ac.config
+	config.InputFile = in.path
+	config.OutputFile = output
+	config.ShowProgress = false
+	config.Interactive = false
+	config.Jobs = jobs
+	config.Seed += int64(i)
+
+	result := batchResult{input: in, output: output}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		result.err = err
+		return result
+	}
+
+	converter := NewASCIIConverter(&config, ac.chatter, ac.phrases)
+	converter.log("Converting %s", in.path)
+	result.stats = converter.stats
+	if result.err = converter.convertImage(in.path); result.err != nil {
+		os.Remove(output)
+		return result
+	}
+	converter.recordAchievements()
+	return result
+}
+
+// printBatchSummary writes one row per file in input order and reports
+// whether all of them converted.
+func (ac *ASCIIConverter) printBatchSummary(results []batchResult) bool {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "STATUS\tINPUT\tOUTPUT\tFRAMES\tTIME\n")
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			fmt.Fprintf(tw, "❌ FAIL\t%s\t%v\t-\t-\n", r.input.path, r.err)
+			continue
+		}
+		fmt.Fprintf(tw, "✅ OK\t%s\t%s\t%d\t%v\n", r.input.path, r.output, r.stats.FrameCount, r.stats.Duration())
+	}
+	tw.Flush()
+	fmt.Printf("\n%d converted, %d failed, %d total in %v\n", len(results)-failed, failed, len(results), ac.stats.Duration())
+
+	if ac.config.BrainrotLevel != "off" && !ac.config.Silent {
+		if failed > 0 {
+			fmt.Fprintf(ac.chatter, "💀 %d of %d files were not very cash money 💀\n", failed, len(results))
+		} else {
+			fmt.Fprintf(ac.chatter, "✨ BATCH COMPLETE - %d FILES ABSOLUTELY COOKED ✨\n", len(results))
+		}
+	}
+	return failed == 0
+}
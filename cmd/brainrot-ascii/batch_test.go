@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// touch creates empty files below dir.
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCollectInputs(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "a/x.png", "a/y.gif", "b/x.png", "d/x.png", "d/sub/z.jpg", "d/notes.txt")
+	in := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name      string
+		args      []string
+		recursive bool
+		rels      []string
+		failed    []bool
+	}{
+		{"single file", []string{in("a/x.png")}, false, []string{"x.png"}, []bool{false}},
+		{"globs from two directories", []string{in("a/*.png"), in("b/*.png")}, false, []string{"a/x.png", "b/x.png"}, []bool{false, false}},
+		{"same file twice", []string{in("a/x.png"), in("a/*.png")}, false, []string{"x.png"}, []bool{false}},
+		{"directory", []string{in("d")}, true, []string{"sub/z.jpg", "x.png"}, []bool{false, false}},
+		{"directory and file", []string{in("d"), in("a/y.gif")}, true, []string{"sub/z.jpg", "x.png", "y.gif"}, []bool{false, false, false}},
+		{"missing file", []string{in("a/x.png"), in("nope.png")}, false, []string{"x.png", in("nope.png")}, []bool{false, true}},
+		{"unmatched glob", []string{in("c/*.png"), in("b/x.png")}, false, []string{in("c/*.png"), "x.png"}, []bool{true, false}},
+		{"directory without -r", []string{in("d")}, false, []string{in("d")}, []bool{true}},
+		{"stdin", []string{"-", "-"}, false, []string{"stdin"}, []bool{false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := collectInputs(tt.args, tt.recursive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rels []string
+			var failed []bool
+			for _, input := range inputs {
+				rels = append(rels, filepath.ToSlash(input.rel))
+				failed = append(failed, input.err != nil)
+			}
+			for i := range tt.rels {
+				tt.rels[i] = filepath.ToSlash(tt.rels[i])
+			}
+			if !reflect.DeepEqual(rels, tt.rels) || !reflect.DeepEqual(failed, tt.failed) {
+				t.Errorf("got %v failed %v, want %v failed %v", rels, failed, tt.rels, tt.failed)
+			}
+		})
+	}
+
+	if _, err := collectInputs([]string{in("[")}, false); err == nil {
+		t.Error("a malformed pattern should stop the batch")
+	}
+}
+
+func TestCommonParent(t *testing.T) {
+	root := string(filepath.Separator)
+	path := func(parts ...string) string { return filepath.Join(append([]string{root}, parts...)...) }
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{path("a", "x.png")}, path("a")},
+		{[]string{path("a", "x.png"), path("a", "y.png")}, path("a")},
+		{[]string{path("a", "x.png"), path("b", "x.png")}, root},
+		{[]string{path("p", "a", "x.png"), path("p", "a", "b", "y.png")}, path("p", "a")},
+		{[]string{path("p", "ab", "x.png"), path("p", "a", "y.png")}, path("p")},
+	}
+	for _, tt := range tests {
+		if got := commonParent(tt.paths); got != tt.want {
+			t.Errorf("commonParent(%v) = %s, want %s", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestBatchOutputs(t *testing.T) {
+	inputs := []batchInput{
+		{path: "a.png", rel: "a.png"},
+		{path: "a.gif", rel: "a.gif"},
+		{path: "b.png", rel: "b.png"},
+		{path: "x/a.png", rel: "x/a.png"},
+		{path: "missing.png", rel: "missing.png", err: os.ErrNotExist},
+		// Keeping the extension would give a.png.txt, which this one
+		// already claims
+		{path: "a.png.jpg", rel: "a.png.jpg"},
+		{path: "y/a.png", rel: "x/a.png"},
+	}
+	want := []string{
+		"out/a.png-2.txt",
+		"out/a.gif.txt",
+		"out/b.txt",
+		"out/x/a.png.txt",
+		"",
+		"out/a.png.txt",
+		"out/x/a.png-2.txt",
+	}
+	got := batchOutputs(inputs, "out", "text")
+	for i := range got {
+		got[i] = filepath.ToSlash(got[i])
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
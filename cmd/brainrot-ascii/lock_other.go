@@ -0,0 +1,40 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// A lock file older than this is left over from a crashed run and is
+// taken over. Profile updates take milliseconds.
+const staleLockAge = 10 * time.Second
+
+// lockFile creates path exclusively, waiting while another invocation holds
+// it. Without flock this is the portable way to get mutual exclusion.
+func lockFile(path string) (unlock func() error, err error) {
+	deadline := time.Now().Add(2 * staleLockAge)
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() error { return os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
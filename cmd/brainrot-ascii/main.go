@@ -0,0 +1,1015 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zsombyy/ASCII-Converter-Genz-Edition/ascii"
+	"github.com/Zsombyy/ASCII-Converter-Genz-Edition/codec"
+)
+
+const (
+	VERSION  = "1.0.0"
+	APP_NAME = "brainrot-ascii"
+)
+
+type Config struct {
+	InputFile      string
+	InputFiles     []string
+	OutputFile     string
+	OutDir         string
+	Recursive      bool
+	BatchJobs      int
+	Width          int
+	Height         int
+	BrainrotLevel  string
+	ASCIISet       string
+	Invert         bool
+	Colorize       bool
+	ColorMode      string
+	Background     string
+	FrameDelay     int
+	Quality        string
+	Verbose        bool
+	Silent         bool
+	LoopGIF        bool
+	LoopCount      int
+	ScaleMode      string
+	Crop           string
+	NoAutoOrient   bool
+	RenderMode     string
+	Dither         string
+	Luma           string
+	Tone           string
+	Threshold      int
+	Contrast       float64
+	Brightness     float64
+	Format         string
+	BrainrotOut    string
+	Seed           int64
+	SeedSet        bool
+	NoPause        bool
+	PhrasePacks    []string
+	NoAchievements bool
+	Jobs           int
+	Interactive    bool
+	ShowProgress   bool
+	Benchmark      bool
+	Profile        bool
+}
+
+type ASCIIConverter struct {
+	config  *Config
+	stats   *ConversionStats
+	options ascii.Options
+	rng     *rand.Rand // every random pick comes from here, see --seed
+	phrases *Phrases
+	
+	// What the source says about itself, passed on to formats that keep it
+	metadata map[string]string
+	
+	// Commentary, logs, progress and stats all go to chatter so the art on
+	// stdout stays byte-clean when it is piped somewhere
+	chatter    io.Writer
+	chatterTTY bool
+	stdoutTTY  bool
+}
+
+// ConversionStats is what templated phrases see, e.g.
+// "bro converted {{.PixelCount}} pixels in {{.Duration}} 💀".
+type ConversionStats struct {
+	StartTime  time.Time
+	EndTime    time.Time
+	FrameCount int
+	PixelCount int64
+	FileSize   int64
+	
+	// Source image, known once it is decoded
+	Width        int
+	Height       int
+	SourceFrames int
+	
+	// Output, known once the conversion is done
+	Columns    int
+	Rows       int
+	Brightness float64 // average brightness 0-255
+	Dominant   color.RGBA
+	
+	Level   string
+	Charset string
+	
+	// A seeded run has to be byte-identical, so its durations read 0
+	frozenClock bool
+}
+
+// Duration is how long the conversion took, or has taken so far, rounded
+// to something readable.
+func (s *ConversionStats) Duration() time.Duration {
+	if s.frozenClock {
+		return 0
+	}
+	end := s.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	d := end.Sub(s.StartTime)
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// Megapixels is the source image size in megapixels.
+func (s *ConversionStats) Megapixels() string {
+	return fmt.Sprintf("%.1f", float64(s.Width*s.Height)/1e6)
+}
+
+// DominantColor is the most common output color as #rrggbb.
+func (s *ConversionStats) DominantColor() string {
+	return fmt.Sprintf("#%02x%02x%02x", s.Dominant.R, s.Dominant.G, s.Dominant.B)
+}
+
+// record adds the outcome of a library conversion to the stats.
+func (s *ConversionStats) record(res *ascii.Result) {
+	s.PixelCount += res.PixelCount
+	s.Columns, s.Rows = res.Width, res.Height
+	s.Brightness = res.Brightness
+	s.Dominant = res.Dominant
+}
+
+func NewASCIIConverter(config *Config, chatter io.Writer, phrases *Phrases) *ASCIIConverter {
+	ac := &ASCIIConverter{
+		config: config,
+		stats: &ConversionStats{
+			StartTime: time.Now(),
+			Level:     config.BrainrotLevel,
+			Charset:   config.ASCIISet,
+			
+			frozenClock: config.SeedSet,
+		},
+		options:    config.options(),
+		rng:        rand.New(rand.NewSource(config.Seed)),
+		phrases:    phrases,
+		chatter:    chatter,
+		chatterTTY: isTerminal(chatter),
+		stdoutTTY:  isTerminal(os.Stdout),
+	}
+	// The noise dither pattern comes from the same seed as the commentary
+	ac.options.Seed = ac.rng.Int63()
+	return ac
+}
+
+// isTerminal reports whether w is a character device, which is as close to
+// "a human is watching" as the standard library gets.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// options translates the CLI config into library options.
+func (config *Config) options() ascii.Options {
+	opts := ascii.DefaultOptions()
+	opts.Width = config.Width
+	opts.Height = config.Height
+	opts.ScaleMode = config.ScaleMode
+	opts.Charset = config.ASCIISet
+	opts.Invert = config.Invert
+	opts.Threshold = config.Threshold
+	opts.Contrast = config.Contrast
+	opts.Brightness = config.Brightness
+	opts.Quality = config.Quality
+	opts.Dither = config.Dither
+	opts.Luma = config.Luma
+	opts.Tone = config.Tone
+	opts.RenderMode = config.RenderMode
+	opts.Jobs = config.Jobs
+	opts.Crop, _ = ascii.ParseCrop(config.Crop)
+	opts.Background, _ = ascii.ParseBackground(config.Background)
+	if config.Colorize {
+		opts.ColorMode = config.ColorMode
+	}
+	return opts
+}
+
+func (ac *ASCIIConverter) log(format string, args ...interface{}) {
+	if ac.config.Verbose && !ac.config.Silent {
+		fmt.Fprintf(ac.chatter, "[DEBUG] "+format+"\n", args...)
+	}
+}
+
+func (ac *ASCIIConverter) dropMotivationalBombshell() {
+	if ac.config.BrainrotLevel == "off" || ac.config.Silent {
+		return
+	}
+
+	if ac.rng.Float64() < ac.phrases.QuoteChance {
+		if quote, ok := pickPhrase(ac.rng, ac.phrases.Quotes); ok {
+			fmt.Fprintf(ac.chatter, "\n%s\n", quote.say(ac.stats))
+		}
+	}
+}
+
+func (ac *ASCIIConverter) triggerRandomBrainrotEvent() {
+	if ac.config.BrainrotLevel == "off" || ac.config.Silent {
+		return
+	}
+
+	if ac.rng.Float64() < ac.phrases.EventChance {
+		event, ok := pickPhrase(ac.rng, ac.phrases.Events)
+		if !ok {
+			return
+		}
+		fmt.Fprintf(ac.chatter, "\n%s\n", event.say(ac.stats))
+		if !ac.config.NoPause {
+			time.Sleep(1 * time.Second) // Dramatic pause
+		}
+	}
+}
+
+func (ac *ASCIIConverter) progress(current, total int, operation string) {
+	// A redrawn bar only makes sense on a terminal, in a file it is noise
+	if !ac.config.ShowProgress || ac.config.Silent || !ac.chatterTTY {
+		return
+	}
+	percent := float64(current) / float64(total) * 100
+	bar := strings.Repeat("█", int(percent/5)) + strings.Repeat("░", 20-int(percent/5))
+	
+	var message string
+	switch {
+	case percent < 25:
+		message = "warming up the sigma energy..."
+	case percent < 50:
+		message = "converting pixels like a chad..."
+	case percent < 75:
+		message = "absolutely demolishing the competition..."
+	case percent < 95:
+		message = "entering the final boss phase..."
+	default:
+		message = "MAXIMUM POWER ACHIEVED"
+	}
+	
+	fmt.Fprintf(ac.chatter, "\r[%s] %.1f%% %s - %s", bar, percent, operation, message)
+	if current == total {
+		fmt.Fprintln(ac.chatter)
+	}
+}
+
+func (ac *ASCIIConverter) printBrainrot(intensity string) {
+	if ac.config.BrainrotLevel == "off" || ac.config.Silent {
+		return
+	}
+	
+	response, ok := pickPhrase(ac.rng, ac.phrases.Responses[intensity])
+	if !ok {
+		return
+	}
+	fmt.Fprintf(ac.chatter, "💬 %s\n", response.say(ac.stats))
+}
+
+// printVerdict is the closing line. When the measured stats hit one of the
+// statFacts the pack has phrases for, the line comes from those tiers,
+// otherwise it is a regular response for intensity.
+func (ac *ASCIIConverter) printVerdict(intensity string) {
+	if ac.config.BrainrotLevel == "off" || ac.config.Silent {
+		return
+	}
+	
+	var tiers []Phrase
+	for _, fact := range statFacts {
+		if fact.holds(ac.stats) {
+			tiers = append(tiers, ac.phrases.Facts[fact.name]...)
+		}
+	}
+	if verdict, ok := pickPhrase(ac.rng, tiers); ok {
+		fmt.Fprintf(ac.chatter, "💬 %s\n", verdict.say(ac.stats))
+		return
+	}
+	ac.printBrainrot(intensity)
+}
+
+// createOutput opens where the art goes: the -o file, created up front so
+// frames stream straight into it instead of piling up in memory, or stdout.
+func (ac *ASCIIConverter) createOutput() (*os.File, error) {
+	if ac.config.OutputFile == "" {
+		return os.Stdout, nil
+	}
+	
+	ac.log("Writing output to: %s", ac.config.OutputFile)
+	file, err := os.Create(ac.config.OutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %v", err)
+	}
+	return file, nil
+}
+
+// closeOutput closes an output opened by createOutput. Closing twice is
+// harmless, so it can be both deferred and checked on the success path.
+func (ac *ASCIIConverter) closeOutput(file *os.File) error {
+	if file == os.Stdout {
+		return nil
+	}
+	if err := file.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
+
+// newOutputEncoder creates the encoder for the configured format on top of w.
+func (ac *ASCIIConverter) newOutputEncoder(w io.Writer, animated bool) (ascii.Encoder, error) {
+	enc, err := ascii.NewEncoder(ac.config.Format, w)
+	if err != nil {
+		return nil, err
+	}
+	
+	depth, _ := ascii.ParseColorDepth(ac.options.ColorMode)
+	info := ascii.OutputInfo{
+		Title:        APP_NAME + " - " + ac.config.ASCIISet,
+		Charset:      ac.config.ASCIISet,
+		ColorDepth:   depth,
+		Animated:     animated,
+		FrameHeaders: animated && ac.config.OutputFile != "" && ac.config.Format == "text",
+		Metadata:     ac.metadata,
+	}
+	if err := enc.Begin(info); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+func (ac *ASCIIConverter) convertGIF(r io.Reader) error {
+	gifImg, err := gif.DecodeAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode GIF: %v", err)
+	}
+	
+	ac.log("GIF loaded: %d frames, %dx%d", len(gifImg.Image), gifImg.Config.Width, gifImg.Config.Height)
+	ac.stats.Width, ac.stats.Height = gifImg.Config.Width, gifImg.Config.Height
+	ac.stats.SourceFrames = len(gifImg.Image)
+	ac.printBrainrot("medium")
+	
+	if !ac.config.Silent {
+		fmt.Fprintf(ac.chatter, "🎬 Converting GIF with %d frames 🎬\n", len(gifImg.Image))
+	}
+	
+	out, err := ac.createOutput()
+	if err != nil {
+		return err
+	}
+	defer ac.closeOutput(out)
+	
+	enc, err := ac.newOutputEncoder(out, true)
+	if err != nil {
+		return err
+	}
+	
+	loops := 1
+	if ac.config.LoopGIF {
+		loops = ac.config.LoopCount
+		if loops <= 0 {
+			loops = -1 // Infinite loop
+		}
+	}
+	
+	// The first loop renders through the frame pipeline, later loops replay
+	// the frames it rendered instead of converting everything again
+	var cache []*ascii.Frame
+	loopCount := 0
+	for loops == -1 || loopCount < loops {
+		if loopCount == 0 {
+			index := 0
+			res, err := ascii.StreamGIF(context.Background(), gifImg, ac.options, func(frame *ascii.Frame) error {
+				if loops != 1 {
+					cache = append(cache, frame)
+				}
+				index++
+				return ac.showFrame(enc, frame, index, len(gifImg.Image), loopCount+1)
+			})
+			if err != nil {
+				return err
+			}
+			ac.stats.record(res)
+		} else {
+			for i, frame := range cache {
+				if err := ac.showFrame(enc, frame, i+1, len(cache), loopCount+1); err != nil {
+					return err
+				}
+			}
+		}
+		loopCount++
+		if loops != -1 && loopCount >= loops {
+			break
+		}
+	}
+	
+	if err := enc.End(); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	
+	ac.stats.FrameCount = len(gifImg.Image) * loopCount
+	ac.stats.EndTime = time.Now()
+	return ac.closeOutput(out)
+}
+
+// showFrame writes one rendered GIF frame and, when playing interactively,
+// holds it on screen for its delay.
+func (ac *ASCIIConverter) showFrame(enc ascii.Encoder, frame *ascii.Frame, index, total, loop int) error {
+	if ac.config.Interactive && ac.config.OutputFile == "" && ac.stdoutTTY {
+		fmt.Print("\033[2J\033[H") // Clear screen and move cursor to top
+	}
+	
+	if ac.config.Verbose && !ac.config.Silent {
+		fmt.Fprintf(ac.chatter, "Frame %d/%d (Loop %d)\n", index, total, loop)
+	}
+	
+	if err := enc.WriteFrame(frame); err != nil {
+		return fmt.Errorf("failed to write frame: %v", err)
+	}
+	
+	ac.progress(index, total, fmt.Sprintf("Processing frame (Loop %d)", loop))
+	
+	if ac.config.Interactive {
+		delay := frame.Delay
+		if delay == 0 {
+			delay = time.Duration(ac.config.FrameDelay) * time.Millisecond
+		}
+		time.Sleep(delay)
+	}
+	return nil
+}
+
+// readInput reads the whole input, from stdin when filename is "-". It is
+// read up front because stdin can't be rewound after sniffing the format.
+func readInput(filename string) ([]byte, error) {
+	if filename == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %v", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %v", err)
+	}
+	return data, nil
+}
+
+// orient reads a JPEG's EXIF metadata and turns the image upright, phones
+// store photos as the sensor saw them and only tag how they were held.
+// Broken metadata is logged and otherwise ignored.
+func (ac *ASCIIConverter) orient(img image.Image, data []byte) image.Image {
+	exif, err := codec.DecodeJPEGEXIF(bytes.NewReader(data))
+	if err != nil {
+		if err != codec.ErrNoEXIF {
+			ac.log("Ignoring EXIF: %v", err)
+		}
+		return img
+	}
+	
+	ac.metadata = exif.Tags()
+	if ac.config.Verbose {
+		tags := make([]string, 0, len(ac.metadata))
+		for name, value := range ac.metadata {
+			tags = append(tags, name+"="+value)
+		}
+		sort.Strings(tags)
+		ac.log("EXIF: %s", strings.Join(tags, " "))
+	}
+	
+	if exif.Orientation == 1 || ac.config.NoAutoOrient {
+		return img
+	}
+	ac.log("Applying EXIF orientation %d", exif.Orientation)
+	return codec.Orient(img, exif.Orientation)
+}
+
+func (ac *ASCIIConverter) convertImage(filename string) error {
+	data, err := readInput(filename)
+	if err != nil {
+		return err
+	}
+	ac.stats.FileSize = int64(len(data))
+	
+	// The format comes from the magic bytes, so names and extensions don't
+	// matter and every decoder registered with the image package works
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return fmt.Errorf("unsupported format: not a JPG, PNG, GIF, BMP, Netpbm, TGA, QOI or farbfeld image")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %v", err)
+	}
+	ac.log("Decoding %s image", format)
+	
+	if format == "gif" {
+		return ac.convertGIF(bytes.NewReader(data))
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %v", err)
+	}
+	if format == "jpeg" {
+		img = ac.orient(img, data)
+	}
+	
+	bounds := img.Bounds()
+	ac.log("Image loaded: %dx%d", bounds.Dx(), bounds.Dy())
+	ac.stats.Width, ac.stats.Height = bounds.Dx(), bounds.Dy()
+	ac.stats.SourceFrames = 1
+	ac.printBrainrot(ac.config.BrainrotLevel)
+	ac.dropMotivationalBombshell()
+	ac.triggerRandomBrainrotEvent()
+	
+	opts := ac.options
+	if ac.config.ShowProgress {
+		opts.Progress = func(done, total int) {
+			ac.progress(done, total, "Converting rows")
+		}
+	}
+	res, err := ascii.Convert(context.Background(), img, opts)
+	if err != nil {
+		return err
+	}
+	ac.stats.record(res)
+	
+	out, err := ac.createOutput()
+	if err != nil {
+		return err
+	}
+	defer ac.closeOutput(out)
+	
+	enc, err := ac.newOutputEncoder(out, false)
+	if err != nil {
+		return err
+	}
+	if err := enc.WriteFrame(res.Frames[0]); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	if err := enc.End(); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	
+	ac.stats.FrameCount = 1
+	ac.stats.EndTime = time.Now()
+	return ac.closeOutput(out)
+}
+
+func (ac *ASCIIConverter) printStats() {
+	if !ac.config.Benchmark || ac.config.Silent {
+		return
+	}
+	
+	duration := ac.stats.EndTime.Sub(ac.stats.StartTime)
+	
+	fmt.Fprintf(ac.chatter, "\n📊 CONVERSION STATS 📊\n")
+	fmt.Fprintf(ac.chatter, "Duration: %v\n", duration)
+	fmt.Fprintf(ac.chatter, "Frames: %d\n", ac.stats.FrameCount)
+	fmt.Fprintf(ac.chatter, "Pixels: %d\n", ac.stats.PixelCount)
+	fmt.Fprintf(ac.chatter, "File Size: %d bytes\n", ac.stats.FileSize)
+	if duration.Seconds() > 0 {
+		fmt.Fprintf(ac.chatter, "Speed: %.2f pixels/sec\n", float64(ac.stats.PixelCount)/duration.Seconds())
+	}
+}
+
+func parseFlags() *Config {
+	config := &Config{
+		Width:         80,
+		Height:        0,
+		BrainrotLevel: "medium",
+		ASCIISet:      "default",
+		FrameDelay:    100,
+		Quality:       "normal",
+		LoopCount:     1,
+		ScaleMode:     "maintain",
+		RenderMode:    "ascii",
+		Dither:        "none",
+		Luma:          "rec601",
+		Tone:          "none",
+		Contrast:      1.0,
+		Brightness:    0.0,
+		Format:        "text",
+	}
+	
+	// Define flags
+	flag.StringVar(&config.OutputFile, "o", "", "Output file")
+	flag.StringVar(&config.OutputFile, "output", "", "Output file")
+	flag.StringVar(&config.OutDir, "out-dir", "", "Output directory for batch conversion")
+	flag.BoolVar(&config.Recursive, "r", false, "Convert directories recursively")
+	flag.BoolVar(&config.Recursive, "recursive", false, "Convert directories recursively")
+	flag.IntVar(&config.BatchJobs, "batch-jobs", 0, "Files converted at once (0 for one per CPU)")
+	flag.IntVar(&config.Width, "w", 80, "ASCII width")
+	flag.IntVar(&config.Width, "width", 80, "ASCII width")
+	flag.IntVar(&config.Height, "h", 0, "ASCII height")
+	flag.IntVar(&config.Height, "height", 0, "ASCII height")
+	flag.StringVar(&config.ScaleMode, "s", "maintain", "Scale mode (maintain, fit, stretch)")
+	flag.StringVar(&config.ScaleMode, "scale-mode", "maintain", "Scale mode (maintain, fit, stretch)")
+	flag.StringVar(&config.ASCIISet, "a", "default", "ASCII character set")
+	flag.StringVar(&config.ASCIISet, "ascii-set", "default", "ASCII character set")
+	flag.BoolVar(&config.Invert, "i", false, "Invert brightness")
+	flag.BoolVar(&config.Invert, "invert", false, "Invert brightness")
+	flag.StringVar(&config.Crop, "crop", "", "Region of interest as x,y,w,h")
+	flag.BoolVar(&config.NoAutoOrient, "no-auto-orient", false, "Ignore the EXIF orientation of JPEGs")
+	flag.StringVar(&config.RenderMode, "render", "ascii", "Render mode (ascii, braille, half, quadrant, sextant)")
+	flag.StringVar(&config.Dither, "dither", "none", "Dithering (none, floyd-steinberg, atkinson, jjn, sierra, bayer, noise)")
+	flag.StringVar(&config.Luma, "luma", "rec601", "Luminance model (rec601, rec709, rec2020, perceptual)")
+	flag.StringVar(&config.Tone, "tone", "none", "Tone mapping (none, autolevels[:PCT], equalize, clahe[:LIMIT])")
+	flag.IntVar(&config.Threshold, "t", 0, "Threshold value (0-255)")
+	flag.IntVar(&config.Threshold, "threshold", 0, "Threshold value (0-255)")
+	flag.Float64Var(&config.Contrast, "c", 1.0, "Contrast adjustment")
+	flag.Float64Var(&config.Contrast, "contrast", 1.0, "Contrast adjustment")
+	flag.Float64Var(&config.Brightness, "b", 0.0, "Brightness adjustment")
+	flag.StringVar(&config.BrainrotLevel, "brainrot", "medium", "Brainrot level (off, mild, medium, maximum, GIGACHAD)")
+	flag.StringVar(&config.BrainrotOut, "brainrot-out", "", "Commentary output file (default: stderr)")
+	flag.Int64Var(&config.Seed, "seed", 0, "Random seed for reproducible runs")
+	flag.BoolVar(&config.NoPause, "no-dramatic-pause", false, "Skip the pause after random events")
+	flag.Func("phrase-pack", "Load a phrase pack (repeatable)", func(path string) error {
+		config.PhrasePacks = append(config.PhrasePacks, path)
+		return nil
+	})
+	flag.BoolVar(&config.NoAchievements, "no-achievements", false, "Don't record this run in the achievements profile")
+	flag.BoolVar(&config.Silent, "silent", false, "Silent mode")
+	flag.IntVar(&config.FrameDelay, "frame-delay", 100, "Frame delay in milliseconds")
+	flag.BoolVar(&config.LoopGIF, "loop", false, "Loop GIF animation")
+	flag.IntVar(&config.LoopCount, "loop-count", 1, "Number of loops (0 for infinite)")
+	flag.BoolVar(&config.Interactive, "interactive", false, "Interactive GIF playback")
+	flag.StringVar(&config.Quality, "quality", "normal", "Resampling quality (fast, normal, high)")
+	flag.IntVar(&config.Jobs, "jobs", 0, "Worker goroutines (0 for one per CPU)")
+	flag.BoolVar(&config.Verbose, "verbose", false, "Verbose output")
+	flag.BoolVar(&config.ShowProgress, "progress", false, "Show progress bar")
+	flag.BoolVar(&config.Benchmark, "benchmark", false, "Show benchmark statistics")
+	flag.BoolVar(&config.Profile, "profile", false, "Enable profiling")
+	flag.StringVar(&config.ColorMode, "color", "none", "Color mode (none, 16, 256, truecolor)")
+	flag.StringVar(&config.Background, "background", "transparent", "Color under transparent regions (transparent, black, white, #rrggbb)")
+	flag.StringVar(&config.Format, "f", "text", "Output format")
+	flag.StringVar(&config.Format, "format", "text", "Output format")
+	
+	var showVersion bool
+	var showHelp bool
+	flag.BoolVar(&showVersion, "version", false, "Show version information")
+	flag.BoolVar(&showHelp, "help", false, "Show help information")
+	
+	flag.Parse()
+	
+	if showVersion {
+		printVersion()
+		os.Exit(0)
+	}
+	if showHelp {
+		printHelp()
+		os.Exit(0)
+	}
+	
+	args := flag.Args()
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "❌ No input file specified\n")
+		fmt.Fprintf(os.Stderr, "Use --help for usage information\n")
+		os.Exit(1)
+	}
+	config.InputFiles = args
+	config.InputFile = args[0]
+	
+	// Validate ASCII set
+	if _, exists := ascii.Charset(config.ASCIISet); !exists {
+		fmt.Fprintf(os.Stderr, "❌ Invalid ASCII set: %s\n", config.ASCIISet)
+		fmt.Fprintf(os.Stderr, "Available sets: %s\n", strings.Join(ascii.CharsetNames(), " "))
+		os.Exit(1)
+	}
+	
+	// Validate output size
+	if config.Width < 0 || config.Height < 0 {
+		fmt.Fprintf(os.Stderr, "❌ Invalid output size: -w %d -h %d\n", config.Width, config.Height)
+		fmt.Fprintf(os.Stderr, "Width and height can't be negative, -h 0 picks the height from the aspect ratio\n")
+		os.Exit(1)
+	}
+	
+	// Infer the output format from the output file unless given explicitly
+	formatSet, seedSet := false, false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "f" || f.Name == "format" {
+			formatSet = true
+		}
+		if f.Name == "seed" {
+			seedSet = true
+		}
+	})
+	config.SeedSet = seedSet
+	if !seedSet {
+		config.Seed = time.Now().UnixNano()
+	}
+	if !formatSet && config.OutputFile != "" {
+		if format := ascii.FormatFromExtension(config.OutputFile); format != "" {
+			config.Format = format
+		}
+	}
+	
+	// Validate output format
+	validFormat := false
+	for _, format := range ascii.Formats() {
+		if config.Format == format {
+			validFormat = true
+			break
+		}
+	}
+	if !validFormat {
+		fmt.Fprintf(os.Stderr, "❌ Invalid output format: %s\n", config.Format)
+		fmt.Fprintf(os.Stderr, "Valid formats: %s\n", strings.Join(ascii.Formats(), ", "))
+		os.Exit(1)
+	}
+	
+	// Validate quality
+	validQuality := false
+	for _, level := range ascii.QualityLevels() {
+		if config.Quality == level {
+			validQuality = true
+			break
+		}
+	}
+	if !validQuality {
+		fmt.Fprintf(os.Stderr, "❌ Invalid quality: %s\n", config.Quality)
+		fmt.Fprintf(os.Stderr, "Valid levels: %s\n", strings.Join(ascii.QualityLevels(), ", "))
+		os.Exit(1)
+	}
+	
+	// Validate worker count
+	if config.Jobs < 0 {
+		fmt.Fprintf(os.Stderr, "❌ Invalid jobs: %d\n", config.Jobs)
+		fmt.Fprintf(os.Stderr, "Use 0 for one worker per CPU\n")
+		os.Exit(1)
+	}
+	if config.BatchJobs < 0 {
+		fmt.Fprintf(os.Stderr, "❌ Invalid batch jobs: %d\n", config.BatchJobs)
+		fmt.Fprintf(os.Stderr, "Use 0 for one file per CPU\n")
+		os.Exit(1)
+	}
+	
+	// Batch output goes to --out-dir, one file per input
+	if config.OutDir != "" && config.OutputFile != "" {
+		fmt.Fprintf(os.Stderr, "❌ Use either -o or --out-dir, not both\n")
+		os.Exit(1)
+	}
+	
+	// Validate crop region
+	if _, err := ascii.ParseCrop(config.Crop); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		fmt.Fprintf(os.Stderr, "Expected --crop x,y,w,h with a positive width and height\n")
+		os.Exit(1)
+	}
+	
+	// Validate render mode
+	validMode := false
+	for _, mode := range ascii.RenderModes() {
+		if config.RenderMode == mode {
+			validMode = true
+			break
+		}
+	}
+	if !validMode {
+		fmt.Fprintf(os.Stderr, "❌ Invalid render mode: %s\n", config.RenderMode)
+		fmt.Fprintf(os.Stderr, "Valid modes: %s\n", strings.Join(ascii.RenderModes(), ", "))
+		os.Exit(1)
+	}
+	
+	// Validate dithering
+	dither, err := ascii.NormalizeDitherMode(config.Dither)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid dither mode: %s\n", config.Dither)
+		fmt.Fprintf(os.Stderr, "Valid modes: %s\n", strings.Join(ascii.DitherModes(), ", "))
+		os.Exit(1)
+	}
+	config.Dither = dither
+	
+	// Validate luma model
+	luma, err := ascii.NormalizeLumaModel(config.Luma)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid luma model: %s\n", config.Luma)
+		fmt.Fprintf(os.Stderr, "Valid models: %s\n", strings.Join(ascii.LumaModels(), ", "))
+		os.Exit(1)
+	}
+	config.Luma = luma
+	
+	// Validate tone mapping
+	if _, err := ascii.ParseTone(config.Tone); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		fmt.Fprintf(os.Stderr, "Valid modes: %s, with autolevels:PERCENT (0-50) or clahe:LIMIT (1 or more)\n", strings.Join(ascii.ToneModes(), ", "))
+		os.Exit(1)
+	}
+	
+	// Validate color mode
+	depth, err := ascii.ParseColorDepth(config.ColorMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid color mode: %s\n", config.ColorMode)
+		fmt.Fprintf(os.Stderr, "Valid modes: none, 16, 256, truecolor\n")
+		os.Exit(1)
+	}
+	config.Colorize = depth != ascii.ColorNone
+	
+	// Validate background
+	if _, err := ascii.ParseBackground(config.Background); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		fmt.Fprintf(os.Stderr, "Expected --background transparent, black, white or a hex color like #1e1e2e\n")
+		os.Exit(1)
+	}
+	
+	// Validate brainrot level
+	validLevels := []string{"off", "mild", "medium", "maximum", "GIGACHAD"}
+	valid := false
+	for _, level := range validLevels {
+		if config.BrainrotLevel == level {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		fmt.Fprintf(os.Stderr, "❌ Invalid brainrot level: %s\n", config.BrainrotLevel)
+		fmt.Fprintf(os.Stderr, "Valid levels: off, mild, medium, maximum, GIGACHAD\n")
+		os.Exit(1)
+	}
+	
+	return config
+}
+
+func printVersion() {
+	fmt.Printf("%s version %s\n", APP_NAME, VERSION)
+	fmt.Printf("Built with Go %s for %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("Maximum brainrot energy included 💀\n")
+}
+
+func printHelp() {
+	fmt.Printf("%s - Convert images to ASCII art with maximum brainrot energy\n\n", APP_NAME)
+	fmt.Printf("Usage: %s [options] <input_file>\n", APP_NAME)
+	fmt.Printf("       %s [options] --out-dir DIR <inputs...>\n", APP_NAME)
+	fmt.Printf("       %s achievements\n\n", APP_NAME)
+	fmt.Printf("Options:\n")
+	fmt.Printf("  -o, --output FILE        Output file (default: stdout)\n")
+	fmt.Printf("  --out-dir DIR            Convert every input into DIR, mirroring directories\n")
+	fmt.Printf("  -r, --recursive          Convert the images in directory inputs, subdirectories included\n")
+	fmt.Printf("  --batch-jobs INT         Files converted at once with --out-dir (default: 0, one per CPU)\n")
+	fmt.Printf("  -w, --width INT          ASCII width (default: 80)\n")
+	fmt.Printf("  -h, --height INT         ASCII height (default: auto)\n")
+	fmt.Printf("  -s, --scale-mode MODE    Scale mode: maintain, fit, stretch (default: maintain)\n")
+	fmt.Printf("  -a, --ascii-set SET      ASCII character set (default: default)\n")
+	fmt.Printf("  -i, --invert             Invert brightness\n")
+	fmt.Printf("  -t, --threshold INT      Threshold value 0-255 (default: 0)\n")
+	fmt.Printf("  --quality LEVEL          Resampling: fast, normal, high or nearest, box, bilinear, lanczos (default: normal)\n")
+	fmt.Printf("  --crop X,Y,W,H           Only convert this region of the image\n")
+	fmt.Printf("  --no-auto-orient         Keep JPEGs as stored instead of turning them upright by EXIF\n")
+	fmt.Printf("  --jobs INT               Worker goroutines (default: 0, one per CPU)\n")
+	fmt.Printf("  --render MODE            Render mode: ascii, braille, half, quadrant, sextant (default: ascii)\n")
+	fmt.Printf("  --dither MODE            Dithering: none, floyd-steinberg, atkinson, jjn, sierra, bayer, noise (default: none)\n")
+	fmt.Printf("  --luma MODEL             Brightness from color: rec601, rec709, rec2020, perceptual (default: rec601)\n")
+	fmt.Printf("  --tone MODE              Automatic tone mapping: none, autolevels[:PCT], equalize, clahe[:LIMIT] (default: none)\n")
+	fmt.Printf("  -c, --contrast FLOAT     Contrast adjustment (default: 1.0)\n")
+	fmt.Printf("  -b, --brightness FLOAT   Brightness adjustment (default: 0.0)\n")
+	fmt.Printf("  --color MODE             ANSI color: none, 16, 256, truecolor (default: none)\n")
+	fmt.Printf("  --background COLOR       Color under transparent regions, or transparent to leave them blank (default: transparent)\n")
+	fmt.Printf("  -f, --format FORMAT      Output format: text, ansi, html, svg, json (default: text, or from -o extension)\n")
+	fmt.Printf("  --brainrot LEVEL         Brainrot level: off, mild, medium, maximum, GIGACHAD (default: medium)\n")
+	fmt.Printf("  --brainrot-out FILE      Send commentary, logs and stats here (default: stderr)\n")
+	fmt.Printf("  --silent                 Silent mode\n")
+	fmt.Printf("  --seed INT               Random seed, same seed and flags give the same output, durations read 0s and achievements go unannounced (default: clock)\n")
+	fmt.Printf("  --no-dramatic-pause      Don't sleep after random brainrot events\n")
+	fmt.Printf("  --phrase-pack FILE       Load a JSON phrase pack on top of the built-in one (repeatable)\n")
+	fmt.Printf("  --no-achievements        Don't record this run or announce achievements\n")
+	fmt.Printf("  --frame-delay INT        Frame delay in ms (default: 100)\n")
+	fmt.Printf("  --loop                   Loop GIF animation\n")
+	fmt.Printf("  --loop-count INT         Number of loops (default: 1, 0 for infinite)\n")
+	fmt.Printf("  --interactive            Interactive GIF playback\n")
+	fmt.Printf("  --verbose                Verbose output\n")
+	fmt.Printf("  --progress               Show progress bar\n")
+	fmt.Printf("  --benchmark              Show benchmark statistics\n")
+	fmt.Printf("  --version                Show version information\n")
+	fmt.Printf("  --help                   Show this help message\n")
+	fmt.Printf("\nSupported formats: JPG, PNG, GIF, BMP, PBM/PGM/PPM, TGA, QOI, farbfeld, detected from the file contents\n")
+	fmt.Printf("Use - as the input file to read the image from stdin\n")
+	fmt.Printf("ASCII sets: default, blocks, dots, classic, simple, minimal, retro, sigma, ohio, rizz, gyatt, skibidi, cringe, based, sussy\n")
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "achievements" {
+		if err := printAchievements(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	
+	config := parseFlags()
+	
+	// Expand globs and directories. Inputs that don't exist fail in the
+	// batch summary, or right here for a single file
+	inputs, err := collectInputs(config.InputFiles, config.Recursive)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "❌ No images found in %s\n", strings.Join(config.InputFiles, ", "))
+		os.Exit(1)
+	}
+	if len(inputs) > 1 && config.OutDir == "" {
+		fmt.Fprintf(os.Stderr, "❌ %d input files need --out-dir to write their outputs to\n", len(inputs))
+		os.Exit(1)
+	}
+	if config.OutDir == "" && inputs[0].err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", inputs[0].err)
+		os.Exit(1)
+	}
+	config.InputFile = inputs[0].path
+	
+	// Commentary never shares stdout with the art
+	chatter := os.Stderr
+	if config.BrainrotOut != "" {
+		file, err := os.OpenFile(config.BrainrotOut, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Cannot open brainrot output: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		chatter = file
+	}
+	
+	phrases, err := loadPhrases(config.PhrasePacks, func(format string, args ...interface{}) {
+		if !config.Silent {
+			fmt.Fprintf(chatter, "⚠️ "+format+"\n", args...)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	
+	// Colored block cells are a best color split, there are no dots to pick
+	blocks := config.RenderMode != "ascii" && config.RenderMode != "braille"
+	if blocks && config.Colorize && (config.Threshold != 0 || config.Dither != "none") && !config.Silent {
+		fmt.Fprintf(chatter, "⚠️ --threshold and --dither have no effect on %s blocks with --color, they only pick the drawn sub-pixels in monochrome\n", config.RenderMode)
+	}
+	tone, _ := ascii.ParseTone(config.Tone)
+	adjusted := config.Contrast != 1 || config.Brightness != 0 || config.Invert || tone.Mode != "none"
+	if blocks && config.Colorize && config.Luma != "rec601" && !adjusted && !config.Silent {
+		fmt.Fprintf(chatter, "⚠️ --luma has no effect on %s blocks with --color unless -c, -b, --invert or --tone adjust the tones\n", config.RenderMode)
+	}
+	
+	converter := NewASCIIConverter(config, chatter, phrases)
+	
+	if config.BrainrotLevel != "off" && !config.Silent {
+		fmt.Fprintln(chatter, "🚀 GEN-Z ASCII CONVERTER ACTIVATED 🚀")
+		if config.Verbose {
+			fmt.Fprintf(chatter, "Platform: %s/%s | Go: %s\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+		}
+	}
+	converter.log("Seed: %d", config.Seed)
+	converter.log("Phrase packs: %s", strings.Join(phrases.Packs, ", "))
+	
+	if config.OutDir != "" {
+		if !converter.runBatch(inputs) {
+			os.Exit(1)
+		}
+		return
+	}
+	
+	err = converter.convertImage(config.InputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Conversion failed: %v\n", err)
+		if config.BrainrotLevel != "off" && !config.Silent {
+			fmt.Fprintf(chatter, "💀 This is not very cash money 💀\n")
+		}
+		os.Exit(1)
+	}
+	
+	converter.printStats()
+	
+	if config.BrainrotLevel != "off" && !config.Silent {
+		if config.BrainrotLevel == "GIGACHAD" {
+			converter.printVerdict("GIGACHAD")
+			fmt.Fprintln(chatter, "🏆 GIGACHAD MODE COMPLETE - REALITY HAS BEEN SUCCESSFULLY HACKED 🏆")
+			fmt.Fprintln(chatter, "👑 YOU ARE NOW THE CEO OF EXISTENCE 👑")
+		} else {
+			converter.printVerdict("maximum")
+			fmt.Fprintln(chatter, "✨ CONVERSION COMPLETE - YOU'RE NOW THE MAIN CHARACTER ✨")
+		}
+	}
+	
+	converter.recordAchievements()
+}
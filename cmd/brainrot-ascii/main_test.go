@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run the CLI instead of the tests, so a
+// test can run main in a process of its own.
+const runMainEnv = "BRAINROT_ASCII_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs the CLI with args in dir, with its state kept there too.
+func runCLI(t *testing.T, dir string, args ...string) (stdout, stderr []byte) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), runMainEnv+"=1", "XDG_STATE_HOME="+dir, "HOME="+dir)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, errOut.Bytes())
+	}
+	return out.Bytes(), errOut.Bytes()
+}
+
+// writeFixture writes a small image with a gradient, a few colors and a
+// transparent corner.
+func writeFixture(t *testing.T, path string) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 48, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 48; x++ {
+			c := color.NRGBA{uint8(x * 5), uint8(y * 8), uint8(255 - x*5), 255}
+			if x < 8 && y < 8 {
+				c.A = 0
+			}
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, filepath.Join(dir, "in.png"))
+	// The fixture is tiny, and the weight outweighs the built-in facts
+	pack := `{"facts": {"tiny_image": [{"text": "took {{.Duration}}", "weight": 1000000}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "pack.json"), []byte(pack), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"noise dither", []string{"--dither", "noise"}},
+		{"braille noise", []string{"--render", "braille", "--dither", "noise"}},
+		{"colored blocks", []string{"--render", "sextant", "--color", "256", "--tone", "clahe"}},
+		{"maximum brainrot", []string{"--brainrot", "maximum", "--dither", "noise", "-a", "cringe"}},
+		{"gigachad verbose", []string{"--brainrot", "GIGACHAD", "--verbose", "--color", "truecolor"}},
+		{"duration phrase", []string{"--brainrot", "maximum", "--phrase-pack", "pack.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Achievements stay on: the first run unlocks some and the second
+			// doesn't, which must not show
+			args := append([]string{"--seed", "42", "--no-dramatic-pause", "-w", "40"}, tt.args...)
+			args = append(args, "in.png")
+
+			stdout1, stderr1 := runCLI(t, dir, args...)
+			stdout2, stderr2 := runCLI(t, dir, args...)
+			if len(stdout1) == 0 {
+				t.Fatal("no output")
+			}
+			if !bytes.Equal(stdout1, stdout2) {
+				t.Errorf("stdout differs between runs:\n%s\n---\n%s", stdout1, stdout2)
+			}
+			if !bytes.Equal(stderr1, stderr2) {
+				t.Errorf("stderr differs between runs:\n%s\n---\n%s", stderr1, stderr2)
+			}
+			if tt.name == "duration phrase" && !bytes.Contains(stderr1, []byte("took 0s")) {
+				t.Errorf("seeded run reported a duration:\n%s", stderr1)
+			}
+		})
+	}
+}
+
+func TestSeedChangesNoise(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, filepath.Join(dir, "in.png"))
+
+	run := func(seed string) []byte {
+		stdout, _ := runCLI(t, dir, "--seed", seed, "--no-dramatic-pause", "--no-achievements", "--brainrot", "off", "--dither", "noise", "-w", "40", "in.png")
+		return stdout
+	}
+	if bytes.Equal(run("1"), run("2")) {
+		t.Error("noise dithering came out the same for different seeds")
+	}
+}
+
+func TestSeededBatchGivesEachFileItsOwnSeed(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"a.png", "b.png", "c.png"}
+	for _, name := range names {
+		writeFixture(t, filepath.Join(dir, name))
+	}
+
+	run := func(outDir string) [][]byte {
+		args := []string{"--seed", "42", "--no-dramatic-pause", "--brainrot", "maximum", "--dither", "noise", "-w", "40", "--out-dir", outDir}
+		runCLI(t, dir, append(args, names...)...)
+		var outputs [][]byte
+		for _, name := range names {
+			data, err := os.ReadFile(filepath.Join(dir, outDir, strings.TrimSuffix(name, ".png")+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs = append(outputs, data)
+		}
+		return outputs
+	}
+	first, second := run("out1"), run("out2")
+	for i := range names {
+		if !bytes.Equal(first[i], second[i]) {
+			t.Errorf("%s differs between seeded runs", names[i])
+		}
+	}
+	// The files are identical, only their seeds tell the noise apart
+	if bytes.Equal(first[0], first[1]) || bytes.Equal(first[1], first[2]) {
+		t.Error("files in a seeded batch came out with the same noise")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// The built-in commentary, loaded like any other pack before the user's.
+//
+//go:embed packs/default.json
+var defaultPack []byte
+
+// Phrase is one line of commentary. In a pack it is either a plain string or
+// {"text": "...", "weight": 2}; a missing weight counts as 1 and a weight of
+// 0 turns the phrase off. Text containing {{ is a text/template executed
+// against the conversion stats when the phrase is said.
+type Phrase struct {
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight"`
+
+	tmpl *template.Template
+}
+
+func (p *Phrase) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = Phrase{Text: text, Weight: 1}
+		return p.compile()
+	}
+
+	var full struct {
+		Text   string   `json:"text"`
+		Weight *float64 `json:"weight"`
+	}
+	if err := json.Unmarshal(data, &full); err != nil {
+		return fmt.Errorf("phrase must be a string or {\"text\", \"weight\"}: %v", err)
+	}
+	*p = Phrase{Text: full.Text, Weight: 1}
+	if full.Weight != nil {
+		p.Weight = *full.Weight
+	}
+	if p.Weight < 0 {
+		return fmt.Errorf("phrase %q has a negative weight", p.Text)
+	}
+	return p.compile()
+}
+
+func (p *Phrase) compile() error {
+	if !strings.Contains(p.Text, "{{") {
+		return nil
+	}
+	tmpl, err := template.New("phrase").Option("missingkey=error").Parse(p.Text)
+	if err != nil {
+		return fmt.Errorf("phrase %q: %v", p.Text, err)
+	}
+	p.tmpl = tmpl
+	return nil
+}
+
+// say returns the text of the phrase for data. A template that fails to run,
+// say because it names a field that doesn't exist, is said as written.
+func (p Phrase) say(data interface{}) string {
+	if p.tmpl == nil {
+		return p.Text
+	}
+	var out strings.Builder
+	if err := p.tmpl.Execute(&out, data); err != nil {
+		return p.Text
+	}
+	return out.String()
+}
+
+// PhrasePack is the JSON format of a phrase pack. Responses are keyed by
+// brainrot level, facts by the names in statFacts. Chances are probabilities
+// from 0 to 1 and override the ones of earlier packs when set. With Replace,
+// the sections this pack fills in drop everything earlier packs put there
+// instead of adding to it.
+type PhrasePack struct {
+	Name        string              `json:"name"`
+	Replace     bool                `json:"replace"`
+	QuoteChance *float64            `json:"quote_chance"`
+	EventChance *float64            `json:"event_chance"`
+	Responses   map[string][]Phrase `json:"responses"`
+	Facts       map[string][]Phrase `json:"facts"`
+	Quotes      []Phrase            `json:"quotes"`
+	Events      []Phrase            `json:"events"`
+}
+
+// Phrases is the merged result of every loaded pack.
+type Phrases struct {
+	QuoteChance float64
+	EventChance float64
+	Responses   map[string][]Phrase
+	Facts       map[string][]Phrase
+	Quotes      []Phrase
+	Events      []Phrase
+	Packs       []string
+}
+
+// statFacts are the measured facts a pack can react to. They are checked
+// once the conversion is done, in this order.
+var statFacts = []struct {
+	name  string
+	holds func(s *ConversionStats) bool
+}{
+	{"huge_image", func(s *ConversionStats) bool { return s.Width*s.Height >= 8000000 }},
+	{"tiny_image", func(s *ConversionStats) bool { return s.Width*s.Height > 0 && s.Width*s.Height <= 64*64 }},
+	{"long_gif", func(s *ConversionStats) bool { return s.SourceFrames >= 50 }},
+	{"dark_image", func(s *ConversionStats) bool { return s.Columns > 0 && s.Brightness < 60 }},
+	{"bright_image", func(s *ConversionStats) bool { return s.Columns > 0 && s.Brightness > 195 }},
+}
+
+func parsePhrasePack(data []byte, source string) (*PhrasePack, error) {
+	var pack PhrasePack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("invalid phrase pack %s: %v", source, err)
+	}
+	for _, chance := range []*float64{pack.QuoteChance, pack.EventChance} {
+		if chance != nil && (*chance < 0 || *chance > 1) {
+			return nil, fmt.Errorf("invalid phrase pack %s: chances must be between 0 and 1", source)
+		}
+	}
+	if pack.Name == "" {
+		pack.Name = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	return &pack, nil
+}
+
+// merge adds a pack on top of what is loaded so far.
+func (ph *Phrases) merge(pack *PhrasePack) {
+	if pack.QuoteChance != nil {
+		ph.QuoteChance = *pack.QuoteChance
+	}
+	if pack.EventChance != nil {
+		ph.EventChance = *pack.EventChance
+	}
+
+	for level, phrases := range pack.Responses {
+		if pack.Replace {
+			ph.Responses[level] = nil
+		}
+		ph.Responses[level] = append(ph.Responses[level], phrases...)
+	}
+	for fact, phrases := range pack.Facts {
+		if pack.Replace {
+			ph.Facts[fact] = nil
+		}
+		ph.Facts[fact] = append(ph.Facts[fact], phrases...)
+	}
+	if pack.Replace && pack.Quotes != nil {
+		ph.Quotes = nil
+	}
+	ph.Quotes = append(ph.Quotes, pack.Quotes...)
+	if pack.Replace && pack.Events != nil {
+		ph.Events = nil
+	}
+	ph.Events = append(ph.Events, pack.Events...)
+	ph.Packs = append(ph.Packs, pack.Name)
+}
+
+// phrasePackDir is where packs are picked up without any flag,
+// ~/.config/brainrot-ascii/packs on Linux.
+func phrasePackDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, APP_NAME, "packs")
+}
+
+// loadPhrases loads the embedded default pack, then every *.json pack in the
+// pack directory in name order, then the --phrase-pack files in the order
+// given. A broken pack in the directory is reported to warn and skipped, an
+// explicitly requested one is an error.
+func loadPhrases(files []string, warn func(format string, args ...interface{})) (*Phrases, error) {
+	ph := &Phrases{
+		Responses: make(map[string][]Phrase),
+		Facts:     make(map[string][]Phrase),
+	}
+
+	builtin, err := parsePhrasePack(defaultPack, "default.json")
+	if err != nil {
+		return nil, err
+	}
+	ph.merge(builtin)
+
+	if dir := phrasePackDir(); dir != "" {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, path := range matches {
+			pack, err := readPhrasePack(path)
+			if err != nil {
+				warn("skipping phrase pack: %v", err)
+				continue
+			}
+			ph.merge(pack)
+		}
+	}
+
+	for _, path := range files {
+		pack, err := readPhrasePack(path)
+		if err != nil {
+			return nil, err
+		}
+		ph.merge(pack)
+	}
+	return ph, nil
+}
+
+func readPhrasePack(path string) (*PhrasePack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read phrase pack: %v", err)
+	}
+	return parsePhrasePack(data, path)
+}
+
+// pickPhrase returns a phrase chosen by weight, false when none can be picked.
+func pickPhrase(rng *rand.Rand, phrases []Phrase) (Phrase, bool) {
+	total := 0.0
+	for _, p := range phrases {
+		total += p.Weight
+	}
+	if total <= 0 {
+		return Phrase{}, false
+	}
+
+	r := rng.Float64() * total
+	for _, p := range phrases {
+		if r < p.Weight {
+			return p, true
+		}
+		r -= p.Weight
+	}
+	// Rounding left r just past the end, the last weighted phrase wins
+	for i := len(phrases) - 1; i >= 0; i-- {
+		if phrases[i].Weight > 0 {
+			return phrases[i], true
+		}
+	}
+	return Phrase{}, false
+}
@@ -0,0 +1,414 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+)
+
+// BMP: a 14 byte file header starting with "BM", one of the DIB headers told
+// apart by their size, an optional palette or set of channel masks, then the
+// pixel rows, bottom to top unless the height is negative.
+func init() {
+	image.RegisterFormat("bmp", "BM", decodeBMP, decodeBMPConfig)
+}
+
+const (
+	bmpRGB            = 0
+	bmpRLE8           = 1
+	bmpRLE4           = 2
+	bmpBitfields      = 3
+	bmpAlphaBitfields = 6
+
+	bmpCoreHeaderSize = 12 // OS/2 1.x and Windows 2.x, 16 bit dimensions
+)
+
+type bmpHeader struct {
+	dataOffset  int
+	headerSize  int
+	width       int
+	height      int
+	topDown     bool
+	depth       int
+	compression int
+	colors      int       // palette entries stored in the file
+	masks       [4]uint32 // R, G, B, A
+	hasMasks    bool
+}
+
+// readBMPHeader reads the file and DIB headers and the masks that follow
+// them. It consumes exactly 14+headerSize bytes plus the masks, so the
+// palette is next.
+func readBMPHeader(r io.Reader) (*bmpHeader, error) {
+	var file [18]byte // the file header and the DIB header size
+	if err := readFull("bmp", r, file[:]); err != nil {
+		return nil, err
+	}
+	if string(file[:2]) != "BM" {
+		return nil, image.ErrFormat
+	}
+	h := &bmpHeader{
+		dataOffset: int(binary.LittleEndian.Uint32(file[10:])),
+		headerSize: int(binary.LittleEndian.Uint32(file[14:])),
+	}
+
+	switch h.headerSize {
+	case bmpCoreHeaderSize, 40, 52, 56, 64, 108, 124:
+	default:
+		return nil, fmt.Errorf("bmp: unsupported header size %d", h.headerSize)
+	}
+	dib := make([]byte, h.headerSize-4)
+	if err := readFull("bmp", r, dib); err != nil {
+		return nil, err
+	}
+
+	if h.headerSize == bmpCoreHeaderSize {
+		h.width = int(binary.LittleEndian.Uint16(dib[0:]))
+		h.height = int(binary.LittleEndian.Uint16(dib[2:]))
+		h.depth = int(binary.LittleEndian.Uint16(dib[6:]))
+	} else {
+		h.width = int(int32(binary.LittleEndian.Uint32(dib[0:])))
+		height := int32(binary.LittleEndian.Uint32(dib[4:]))
+		h.height = int(height)
+		if height < 0 {
+			h.height, h.topDown = -int(height), true
+		}
+		h.depth = int(binary.LittleEndian.Uint16(dib[10:]))
+		h.compression = int(binary.LittleEndian.Uint32(dib[12:]))
+		h.colors = int(binary.LittleEndian.Uint32(dib[28:]))
+	}
+
+	switch h.depth {
+	case 1, 2, 4, 8, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("bmp: unsupported bit depth %d", h.depth)
+	}
+	// OS/2 2.x reuses compression 3 and 4 for Huffman and 24 bit RLE
+	validCompression := false
+	switch h.compression {
+	case bmpRGB:
+		validCompression = true
+	case bmpRLE8:
+		validCompression = h.depth == 8
+	case bmpRLE4:
+		validCompression = h.depth == 4
+	case bmpBitfields, bmpAlphaBitfields:
+		validCompression = (h.depth == 16 || h.depth == 32) && h.headerSize != 64
+	}
+	if !validCompression {
+		return nil, fmt.Errorf("bmp: unsupported compression %d for %d bits per pixel", h.compression, h.depth)
+	}
+	if h.topDown && (h.compression == bmpRLE8 || h.compression == bmpRLE4) {
+		return nil, fmt.Errorf("bmp: run-length encoded images can't be top-down")
+	}
+
+	if h.depth <= 8 {
+		if h.colors == 0 || h.colors > 1<<h.depth {
+			h.colors = 1 << h.depth
+		}
+	} else {
+		h.colors = 0
+	}
+
+	// Masks live in the larger headers, or right after a 40 byte one
+	if h.compression == bmpBitfields || h.compression == bmpAlphaBitfields {
+		h.hasMasks = true
+		var raw []byte
+		switch {
+		case h.headerSize >= 52:
+			raw = dib[36:]
+		case h.compression == bmpAlphaBitfields:
+			raw = make([]byte, 16)
+		default:
+			raw = make([]byte, 12)
+		}
+		if h.headerSize < 52 {
+			if err := readFull("bmp", r, raw); err != nil {
+				return nil, err
+			}
+		}
+		for i := 0; i < 4 && i*4+4 <= len(raw); i++ {
+			h.masks[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+		if h.compression == bmpBitfields && h.headerSize < 56 {
+			h.masks[3] = 0
+		}
+	}
+
+	return h, checkSize("bmp", h.width, h.height)
+}
+
+// maskBytes is how many bytes of masks followed the DIB header.
+func (h *bmpHeader) maskBytes() int {
+	if !h.hasMasks || h.headerSize >= 52 {
+		return 0
+	}
+	if h.compression == bmpAlphaBitfields {
+		return 16
+	}
+	return 12
+}
+
+func decodeBMPConfig(r io.Reader) (image.Config, error) {
+	h, err := readBMPHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: h.width, Height: h.height}, nil
+}
+
+func decodeBMP(r io.Reader) (image.Image, error) {
+	h, err := readBMPHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	read := 14 + h.headerSize + h.maskBytes()
+
+	var palette color.Palette
+	if h.depth <= 8 {
+		entrySize := 4
+		if h.headerSize == bmpCoreHeaderSize {
+			entrySize = 3
+		}
+		raw := make([]byte, h.colors*entrySize)
+		if err := readFull("bmp", r, raw); err != nil {
+			return nil, err
+		}
+		read += len(raw)
+
+		// Indexes past the stored entries show as black instead of
+		// panicking in Paletted.At
+		palette = make(color.Palette, 1<<h.depth)
+		for i := range palette {
+			palette[i] = color.RGBA{A: 0xff}
+			if i < h.colors {
+				p := raw[i*entrySize:]
+				palette[i] = color.RGBA{R: p[2], G: p[1], B: p[0], A: 0xff}
+			}
+		}
+	}
+
+	if h.dataOffset > read {
+		if _, err := io.CopyN(io.Discard, r, int64(h.dataOffset-read)); err != nil {
+			return nil, fmt.Errorf("bmp: truncated image")
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case h.compression == bmpRLE8 || h.compression == bmpRLE4:
+		return decodeBMPRLE(h, palette, data)
+	case h.depth <= 8:
+		return decodeBMPPaletted(h, palette, data)
+	}
+	return decodeBMPTrueColor(h, data)
+}
+
+// bmpRows returns the stride of the stored rows, padded to 4 bytes, and
+// checks data holds all of them.
+func bmpRows(h *bmpHeader, data []byte) (int, error) {
+	stride := (h.width*h.depth + 31) / 32 * 4
+	if len(data) < stride*h.height {
+		return 0, fmt.Errorf("bmp: truncated image")
+	}
+	return stride, nil
+}
+
+// row returns the image row stored at position i in the file.
+func (h *bmpHeader) row(i int) int {
+	if h.topDown {
+		return i
+	}
+	return h.height - 1 - i
+}
+
+func decodeBMPPaletted(h *bmpHeader, palette color.Palette, data []byte) (image.Image, error) {
+	stride, err := bmpRows(h, data)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewPaletted(image.Rect(0, 0, h.width, h.height), palette)
+	perByte := 8 / h.depth
+	mask := byte(1<<h.depth - 1)
+	for i := 0; i < h.height; i++ {
+		src := data[i*stride:]
+		dst := img.Pix[h.row(i)*img.Stride:]
+		for x := 0; x < h.width; x++ {
+			shift := uint(8 - h.depth*(x%perByte+1))
+			dst[x] = src[x/perByte] >> shift & mask
+		}
+	}
+	return img, nil
+}
+
+// bmpChannel extracts the bits of one mask from v and scales them to 8 bits.
+type bmpChannel struct {
+	mask  uint32
+	shift int
+	max   uint32
+}
+
+func newBMPChannel(mask uint32) bmpChannel {
+	if mask == 0 {
+		return bmpChannel{}
+	}
+	shift := bits.TrailingZeros32(mask)
+	return bmpChannel{mask: mask, shift: shift, max: mask >> shift}
+}
+
+func (c bmpChannel) get(v uint32) uint8 {
+	return scaleTo8((v&c.mask)>>c.shift, c.max)
+}
+
+func decodeBMPTrueColor(h *bmpHeader, data []byte) (image.Image, error) {
+	stride, err := bmpRows(h, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without masks 16 bit is 5-5-5 and 32 bit is BGR with an unused byte
+	masks := h.masks
+	if !h.hasMasks {
+		switch h.depth {
+		case 16:
+			masks = [4]uint32{0x7c00, 0x03e0, 0x001f, 0}
+		case 32:
+			masks = [4]uint32{0x00ff0000, 0x0000ff00, 0x000000ff, 0}
+		}
+	}
+	r, g, b, a := newBMPChannel(masks[0]), newBMPChannel(masks[1]), newBMPChannel(masks[2]), newBMPChannel(masks[3])
+
+	img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+	visible := false
+	for i := 0; i < h.height; i++ {
+		src := data[i*stride:]
+		dst := img.Pix[h.row(i)*img.Stride:]
+		for x := 0; x < h.width; x++ {
+			d := dst[x*4 : x*4+4]
+			if h.depth == 24 {
+				d[0], d[1], d[2], d[3] = src[x*3+2], src[x*3+1], src[x*3], 0xff
+				continue
+			}
+
+			var v uint32
+			if h.depth == 16 {
+				v = uint32(binary.LittleEndian.Uint16(src[x*2:]))
+			} else {
+				v = binary.LittleEndian.Uint32(src[x*4:])
+			}
+			d[0], d[1], d[2], d[3] = r.get(v), g.get(v), b.get(v), 0xff
+			if a.mask != 0 {
+				d[3] = a.get(v)
+			}
+			if d[3] != 0 {
+				visible = true
+			}
+		}
+	}
+
+	// An alpha mask over bytes that are all zero is an image that never
+	// meant to have alpha, not a blank one
+	if a.mask != 0 && !visible {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img, nil
+}
+
+// decodeBMPRLE unpacks RLE8 and RLE4. Pixels the encoder skipped over with
+// end-of-line or delta stay index 0, but the data has to reach the last row.
+// Rows grow as pixels land in them and the image is only allocated once the
+// data is complete, so a few bytes claiming a huge bitmap can't allocate for it.
+func decodeBMPRLE(h *bmpHeader, palette color.Palette, data []byte) (image.Image, error) {
+	rows := make([][]byte, h.height) // stored rows, bottom up
+	x, i := 0, 0
+	put := func(index byte) {
+		if x < h.width && i < h.height {
+			for len(rows[i]) <= x {
+				rows[i] = append(rows[i], 0)
+			}
+			rows[i][x] = index
+		}
+		x++
+	}
+	done := func() (image.Image, error) {
+		img := image.NewPaletted(image.Rect(0, 0, h.width, h.height), palette)
+		for i, row := range rows {
+			copy(img.Pix[h.row(i)*img.Stride:], row)
+		}
+		return img, nil
+	}
+	nibble := func(b byte, k int) byte {
+		if k%2 == 0 {
+			return b >> 4
+		}
+		return b & 0x0f
+	}
+
+	pos := 0
+	for {
+		if pos+2 > len(data) {
+			return nil, fmt.Errorf("bmp: truncated run-length data")
+		}
+		count, value := int(data[pos]), data[pos+1]
+		pos += 2
+
+		if count > 0 {
+			// Encoded run: count pixels of one index, or of two alternating
+			// indexes for RLE4
+			for k := 0; k < count; k++ {
+				if h.compression == bmpRLE4 {
+					put(nibble(value, k))
+				} else {
+					put(value)
+				}
+			}
+			continue
+		}
+
+		switch value {
+		case 0: // end of line
+			x, i = 0, i+1
+		case 1: // end of bitmap
+			if i < h.height-1 {
+				return nil, fmt.Errorf("bmp: run-length data ends before the bitmap is filled")
+			}
+			return done()
+		case 2: // delta
+			if pos+2 > len(data) {
+				return nil, fmt.Errorf("bmp: truncated run-length data")
+			}
+			x += int(data[pos])
+			i += int(data[pos+1])
+			pos += 2
+		default:
+			// Absolute run of value literal pixels, padded to 16 bits
+			n := int(value)
+			size := n
+			if h.compression == bmpRLE4 {
+				size = (n + 1) / 2
+			}
+			if pos+size > len(data) {
+				return nil, fmt.Errorf("bmp: truncated run-length data")
+			}
+			for k := 0; k < n; k++ {
+				if h.compression == bmpRLE4 {
+					put(nibble(data[pos+k/2], k))
+				} else {
+					put(data[pos+k])
+				}
+			}
+			pos += size + size%2
+		}
+		if i >= h.height {
+			return done()
+		}
+	}
+}
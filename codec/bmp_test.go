@@ -0,0 +1,305 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// bmpSpec describes a BMP file for bmpFile to build.
+type bmpSpec struct {
+	headerSize    int
+	width, height int
+	depth         int
+	compression   int
+	colors        int           // palette entries declared, 0 for all of them
+	masks         []uint32      // in headers of 52 bytes and up, after a 40 byte one otherwise
+	palette       []color.NRGBA // stored as 3 bytes each in core headers, 4 otherwise
+	gap           int           // unused bytes before the pixel data
+	data          []byte
+}
+
+func bmpFile(s bmpSpec) []byte {
+	dib := make([]byte, s.headerSize)
+	binary.LittleEndian.PutUint32(dib, uint32(s.headerSize))
+	if s.headerSize == bmpCoreHeaderSize {
+		binary.LittleEndian.PutUint16(dib[4:], uint16(s.width))
+		binary.LittleEndian.PutUint16(dib[6:], uint16(s.height))
+		binary.LittleEndian.PutUint16(dib[8:], 1)
+		binary.LittleEndian.PutUint16(dib[10:], uint16(s.depth))
+	} else {
+		binary.LittleEndian.PutUint32(dib[4:], uint32(int32(s.width)))
+		binary.LittleEndian.PutUint32(dib[8:], uint32(int32(s.height)))
+		binary.LittleEndian.PutUint16(dib[12:], 1)
+		binary.LittleEndian.PutUint16(dib[14:], uint16(s.depth))
+		binary.LittleEndian.PutUint32(dib[16:], uint32(s.compression))
+		binary.LittleEndian.PutUint32(dib[32:], uint32(s.colors))
+	}
+	var masks []byte
+	for i, m := range s.masks {
+		if s.headerSize >= 52 {
+			binary.LittleEndian.PutUint32(dib[40+i*4:], m)
+		} else {
+			masks = binary.LittleEndian.AppendUint32(masks, m)
+		}
+	}
+	var palette []byte
+	for _, c := range s.palette {
+		palette = append(palette, c.B, c.G, c.R)
+		if s.headerSize != bmpCoreHeaderSize {
+			palette = append(palette, 0)
+		}
+	}
+
+	offset := 14 + len(dib) + len(masks) + len(palette) + s.gap
+	data := []byte("BM")
+	data = binary.LittleEndian.AppendUint32(data, uint32(offset+len(s.data)))
+	data = append(data, 0, 0, 0, 0)
+	data = binary.LittleEndian.AppendUint32(data, uint32(offset))
+	data = append(data, dib...)
+	data = append(data, masks...)
+	data = append(data, palette...)
+	data = append(data, make([]byte, s.gap)...)
+	return append(data, s.data...)
+}
+
+// bmpPixelRows stores rows of encoded pixels, each padded to 4 bytes.
+func bmpPixelRows(rows [][]color.NRGBA, bottomUp bool, encode func(color.NRGBA) []byte) []byte {
+	var data []byte
+	for i := range rows {
+		row := rows[i]
+		if bottomUp {
+			row = rows[len(rows)-1-i]
+		}
+		start := len(data)
+		for _, c := range row {
+			data = append(data, encode(c)...)
+		}
+		data = append(data, make([]byte, (4-(len(data)-start)%4)%4)...)
+	}
+	return data
+}
+
+// bmpIndexRows packs bottom-up rows of palette indexes, most significant
+// bits first, each row padded to 4 bytes.
+func bmpIndexRows(rows [][]byte, depth int) []byte {
+	var data []byte
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := make([]byte, (len(rows[i])*depth+31)/32*4)
+		for x, index := range rows[i] {
+			bit := x * depth
+			row[bit/8] |= index << (8 - depth - bit%8)
+		}
+		data = append(data, row...)
+	}
+	return data
+}
+
+func le16(c color.NRGBA, r, g, b int) []byte {
+	v := uint16(c.R)>>(8-r)<<(g+b) | uint16(c.G)>>(8-g)<<b | uint16(c.B)>>(8-b)
+	return binary.LittleEndian.AppendUint16(nil, v)
+}
+
+func decodeBMPBytes(data []byte) error {
+	_, err := decodeBMP(bytes.NewReader(data))
+	return err
+}
+
+var (
+	refPalette = []color.NRGBA{red, green, blue, white, black, gray}
+	refIndexes = [][]byte{{0, 1, 2}, {3, 4, 5}}
+	// Masks for 32 bit pixels stored as BGRA bytes
+	bgraMasks = []uint32{0x00ff0000, 0x0000ff00, 0x000000ff, 0xff000000}
+)
+
+func TestBMP(t *testing.T) {
+	translucent := [][]color.NRGBA{
+		{red, {0, 255, 0, 128}, blue},
+		{white, black, {}},
+	}
+	rgba := func(c color.NRGBA) []byte { return []byte{c.A, c.B, c.G, c.R} }
+	bgrx := func(c color.NRGBA) []byte { return []byte{c.B, c.G, c.R, 0x55} }
+	rgb555 := func(c color.NRGBA) []byte { return le16(c, 5, 5, 5) }
+	rgb565 := func(c color.NRGBA) []byte { return le16(c, 5, 6, 5) }
+	var noAlpha [][]color.NRGBA
+	for _, row := range ref {
+		var out []color.NRGBA
+		for _, c := range row {
+			c.A = 0
+			out = append(out, c)
+		}
+		noAlpha = append(noAlpha, out)
+	}
+	core16 := append(append([]color.NRGBA(nil), refPalette...), make([]color.NRGBA, 10)...)
+
+	decodeCases(t, []codecCase{
+		{"24-bit bottom-up", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 24,
+			data: bmpPixelRows(ref, true, bgr),
+		}), "bmp", ref},
+		{"24-bit top-down", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: -2, depth: 24,
+			data: bmpPixelRows(ref, false, bgr),
+		}), "bmp", ref},
+		{"24-bit after a gap", bmpFile(bmpSpec{
+			headerSize: 124, width: 3, height: 2, depth: 24, gap: 6,
+			data: bmpPixelRows(ref, true, bgr),
+		}), "bmp", ref},
+		{"OS/2 1.x core header", bmpFile(bmpSpec{
+			headerSize: bmpCoreHeaderSize, width: 3, height: 2, depth: 24,
+			data: bmpPixelRows(ref, true, bgr),
+		}), "bmp", ref},
+		{"OS/2 2.x header", bmpFile(bmpSpec{
+			headerSize: 64, width: 3, height: 2, depth: 24,
+			data: bmpPixelRows(ref, true, bgr),
+		}), "bmp", ref},
+		{"8-bit palette", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 8, colors: 6, palette: refPalette,
+			data: bmpIndexRows(refIndexes, 8),
+		}), "bmp", ref},
+		{"4-bit palette", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 4, colors: 6, palette: refPalette,
+			data: bmpIndexRows(refIndexes, 4),
+		}), "bmp", ref},
+		{"4-bit palette in a core header", bmpFile(bmpSpec{
+			headerSize: bmpCoreHeaderSize, width: 3, height: 2, depth: 4, palette: core16,
+			data: bmpIndexRows(refIndexes, 4),
+		}), "bmp", ref},
+		{"2-bit palette", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 2, palette: refPalette[:4],
+			data: bmpIndexRows([][]byte{{0, 1, 2}, {3, 0, 1}}, 2),
+		}), "bmp", [][]color.NRGBA{{red, green, blue}, {white, red, green}}},
+		{"1-bit palette", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 1, palette: []color.NRGBA{black, white},
+			data: bmpIndexRows([][]byte{{1, 0, 1}, {0, 0, 1}}, 1),
+		}), "bmp", [][]color.NRGBA{{white, black, white}, {black, black, white}}},
+		{"index past the palette", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 1, depth: 8, colors: 2, palette: refPalette[:2],
+			data: bmpIndexRows([][]byte{{0, 1, 5}}, 8),
+		}), "bmp", [][]color.NRGBA{{red, green, black}}},
+		{"16-bit default 5-5-5", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 16,
+			data: bmpPixelRows(ref, true, rgb555),
+		}), "bmp", [][]color.NRGBA{{red, green, blue}, {white, black, gray5}}},
+		{"16-bit 5-6-5 bitfields", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 16, compression: bmpBitfields,
+			masks: []uint32{0xf800, 0x07e0, 0x001f},
+			data:  bmpPixelRows(ref, true, rgb565),
+		}), "bmp", [][]color.NRGBA{{red, green, blue}, {white, black, {132, 130, 132, 255}}}},
+		{"32-bit default ignores the fourth byte", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 32,
+			data: bmpPixelRows(ref, true, bgrx),
+		}), "bmp", ref},
+		{"32-bit bitfields ignore alpha after a 40 byte header", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 32, compression: bmpBitfields,
+			masks: bgraMasks[:3],
+			data:  bmpPixelRows(ref, true, bgrx),
+		}), "bmp", ref},
+		{"32-bit alpha in a V4 header", bmpFile(bmpSpec{
+			headerSize: 108, width: 3, height: 2, depth: 32, compression: bmpBitfields,
+			masks: bgraMasks,
+			data:  bmpPixelRows(translucent, true, bgra),
+		}), "bmp", translucent},
+		{"alpha bitfields after a 40 byte header", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: -2, depth: 32, compression: bmpAlphaBitfields,
+			masks: []uint32{0xff000000, 0x00ff0000, 0x0000ff00, 0x000000ff},
+			data:  bmpPixelRows(translucent, false, rgba),
+		}), "bmp", translucent},
+		{"alpha mask over zero bytes", bmpFile(bmpSpec{
+			headerSize: 124, width: 3, height: 2, depth: 32, compression: bmpBitfields,
+			masks: bgraMasks,
+			data:  bmpPixelRows(noAlpha, true, bgra),
+		}), "bmp", ref},
+		{"RLE8 with a delta", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 8, compression: bmpRLE8, colors: 6, palette: refPalette,
+			data: []byte{
+				2, 3, // two white
+				0, 2, 1, 0, // skip one pixel, left at index 0
+				0, 0, // end of line
+				0, 3, 0, 1, 2, 0, // three literal pixels, padded
+				0, 1, // end of bitmap
+			},
+		}), "bmp", [][]color.NRGBA{{red, green, blue}, {white, white, red}}},
+		{"RLE8 ending within the last row", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 8, compression: bmpRLE8, colors: 6, palette: refPalette,
+			data: []byte{3, 1, 0, 0, 1, 2, 0, 1},
+		}), "bmp", [][]color.NRGBA{{blue, red, red}, {green, green, green}}},
+		{"RLE4", bmpFile(bmpSpec{
+			headerSize: 40, width: 3, height: 2, depth: 4, compression: bmpRLE4, colors: 6, palette: refPalette,
+			data: []byte{
+				0, 3, 0x34, 0x50, // three literal pixels
+				0, 0, // end of line
+				2, 0x01, // two pixels alternating indexes 0 and 1
+				1, 0x20,
+				0, 1, // end of bitmap
+			},
+		}), "bmp", ref},
+	})
+}
+
+func TestBMPErrors(t *testing.T) {
+	truecolor := bmpSpec{headerSize: 40, width: 3, height: 2, depth: 24, data: bmpPixelRows(ref, true, bgr)}
+	paletted := bmpSpec{headerSize: 40, width: 3, height: 2, depth: 8, colors: 6, palette: refPalette, data: bmpIndexRows(refIndexes, 8)}
+	bitfields := bmpSpec{
+		headerSize: 40, width: 1, height: 1, depth: 16, compression: bmpBitfields,
+		masks: []uint32{0xf800, 0x07e0, 0x001f}, data: []byte{1, 2, 0, 0},
+	}
+	rle := bmpSpec{
+		headerSize: 40, width: 3, height: 2, depth: 4, compression: bmpRLE4, colors: 6, palette: refPalette,
+		data: []byte{0, 3, 0x34, 0x50, 0, 0, 0, 2, 1, 0, 2, 0x11, 0, 1},
+	}
+	with := func(s bmpSpec, change func(*bmpSpec)) []byte {
+		change(&s)
+		return bmpFile(s)
+	}
+	headerSize := func(size byte) []byte {
+		data := bmpFile(truecolor)
+		data[14] = size
+		return data
+	}
+
+	decodeErrors(t, decodeBMPBytes, []errorCase{
+		{"short file header", bmpFile(truecolor)[:10], "truncated"},
+		{"short DIB header", bmpFile(truecolor)[:40], "truncated"},
+		{"unsupported header size", headerSize(20), "header size"},
+		{"3-bit", with(truecolor, func(s *bmpSpec) { s.depth = 3 }), "bit depth"},
+		{"RLE8 at 4 bits", with(rle, func(s *bmpSpec) { s.compression = bmpRLE8 }), "compression"},
+		{"bitfields at 24 bits", with(truecolor, func(s *bmpSpec) { s.compression = bmpBitfields }), "compression"},
+		{"bitfields in an OS/2 2.x header", with(bitfields, func(s *bmpSpec) { s.headerSize = 64 }), "compression"},
+		{"Huffman", with(truecolor, func(s *bmpSpec) { s.depth, s.compression = 1, 3 }), "compression"},
+		{"top-down RLE", with(rle, func(s *bmpSpec) { s.height = -2 }), "top-down"},
+		{"zero width", with(truecolor, func(s *bmpSpec) { s.width = 0 }), "invalid dimensions"},
+		{"oversized header", with(truecolor, func(s *bmpSpec) { s.width, s.height = 100000, 100000 }), "too large"},
+		{"short masks", bmpFile(bitfields)[:60], "truncated"},
+		{"short palette", bmpFile(paletted)[:70], "truncated"},
+		{"short rows", with(truecolor, func(s *bmpSpec) { s.data = s.data[:20] }), "truncated"},
+		{"short gap", with(truecolor, func(s *bmpSpec) { s.gap, s.data = 10, nil })[:60], "truncated"},
+		{"RLE without an end", with(rle, func(s *bmpSpec) { s.data = s.data[:10] }), "run-length"},
+		{"short delta", with(rle, func(s *bmpSpec) { s.data = s.data[:9] }), "run-length"},
+		{"short literal run", with(rle, func(s *bmpSpec) { s.data = s.data[:3] }), "run-length"},
+		{"RLE ending before the last row", with(rle, func(s *bmpSpec) { s.data = []byte{3, 1, 0, 1} }), "before the bitmap is filled"},
+	})
+
+	truncations(t, decodeBMPBytes, bmpFile(truecolor), 0)
+	truncations(t, decodeBMPBytes, bmpFile(paletted), 0)
+	truncations(t, decodeBMPBytes, bmpFile(bitfields), 0)
+	truncations(t, decodeBMPBytes, bmpFile(rle), 0)
+}
+
+func TestBMPShortFileAllocation(t *testing.T) {
+	for _, s := range []bmpSpec{
+		{headerSize: 40, width: 8192, height: 8192, depth: 32, data: []byte{1, 2, 3, 4}},
+		{headerSize: 40, width: 8192, height: 8192, depth: 8, data: []byte{1, 2, 3, 4}},
+		// Only the end of the bitmap
+		{headerSize: 40, width: 8192, height: 8192, depth: 8, compression: bmpRLE8, data: []byte{0, 1}},
+		// A pixel at the end of every row, skipping to it with a delta
+		{headerSize: 40, width: 8192, height: 8192, depth: 8, compression: bmpRLE8,
+			data: bytes.Repeat([]byte{0, 2, 255, 0, 0, 2, 255, 0, 1, 7, 0, 0}, 4096)},
+	} {
+		data := bmpFile(s)
+		if n := allocated(func() { decodeBMPBytes(data) }); n > shortFileLimit {
+			t.Errorf("%d-bit compression %d with %d bytes of data allocated %d bytes", s.depth, s.compression, len(s.data), n)
+		}
+	}
+}
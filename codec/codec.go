@@ -0,0 +1,78 @@
+// Package codec adds pure Go decoders for image formats the standard library
+// doesn't read: BMP (including RLE), Netpbm P1-P6, TGA, QOI and farbfeld.
+// Like image/png, it registers them with the image package when imported, so
+// image.Decode and image.DecodeConfig recognize them by their magic bytes:
+//
+//	import _ "github.com/Zsombyy/ASCII-Converter-Genz-Edition/codec"
+//
+// It also reads the EXIF metadata of JPEGs, which image/jpeg skips, and can
+// turn an image upright by its EXIF orientation.
+package codec
+
+import (
+	"fmt"
+	"io"
+)
+
+// maxPixels caps the size a header may claim before anything is allocated,
+// so a corrupt or hostile header can't ask for gigabytes. 8192x8192 fits.
+const maxPixels = 1 << 26
+
+// checkSize validates image dimensions read from a header.
+func checkSize(format string, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%s: invalid dimensions %dx%d", format, width, height)
+	}
+	if width > maxPixels/height {
+		return fmt.Errorf("%s: image too large (%dx%d)", format, width, height)
+	}
+	return nil
+}
+
+// readFull is io.ReadFull that reports running out of data as a truncated
+// image instead of a bare EOF.
+func readFull(format string, r io.Reader, buf []byte) error {
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%s: truncated image", format)
+		}
+		return err
+	}
+	return nil
+}
+
+// readData reads exactly n bytes of pixel data. The buffer grows with what
+// actually arrives instead of being sized from the header up front, so a
+// short file claiming a huge image fails without allocating for it.
+func readData(format string, r io.Reader, n int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < n {
+		return nil, fmt.Errorf("%s: truncated image", format)
+	}
+	return data, nil
+}
+
+// scaleTo8 maps v from 0..max onto 0..255, rounding to nearest.
+func scaleTo8(v, max uint32) uint8 {
+	if max == 0 {
+		return 0
+	}
+	if v > max {
+		v = max
+	}
+	return uint8((v*255 + max/2) / max)
+}
+
+// scaleTo16 maps v from 0..max onto 0..65535, rounding to nearest.
+func scaleTo16(v, max uint32) uint16 {
+	if max == 0 {
+		return 0
+	}
+	if v > max {
+		v = max
+	}
+	return uint16((uint64(v)*65535 + uint64(max)/2) / uint64(max))
+}
@@ -0,0 +1,173 @@
+package codec
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// The colors most fixtures are built from. ref is the 3x2 image they draw
+// unless a test says otherwise.
+var (
+	red   = color.NRGBA{255, 0, 0, 255}
+	green = color.NRGBA{0, 255, 0, 255}
+	blue  = color.NRGBA{0, 0, 255, 255}
+	white = color.NRGBA{255, 255, 255, 255}
+	black = color.NRGBA{0, 0, 0, 255}
+	gray  = color.NRGBA{128, 128, 128, 255}
+	// gray at 5 bits per channel, 16 of 31
+	gray5 = color.NRGBA{132, 132, 132, 255}
+
+	ref = [][]color.NRGBA{
+		{red, green, blue},
+		{white, black, gray},
+	}
+)
+
+// codecCase is one fixture and the pixels it should decode to.
+type codecCase struct {
+	name   string
+	data   []byte
+	format string
+	want   [][]color.NRGBA
+}
+
+// decodeCases decodes every case through image.Decode, so the format is
+// sniffed like any file would be, and compares it pixel by pixel.
+func decodeCases(t *testing.T, tests []codecCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := image.Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Errorf("sniffed as %s, want %s", format, tt.format)
+			}
+			checkPixels(t, img, tt.want)
+
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != len(tt.want[0]) || cfg.Height != len(tt.want) {
+				t.Errorf("config says %dx%d, want %dx%d", cfg.Width, cfg.Height, len(tt.want[0]), len(tt.want))
+			}
+		})
+	}
+}
+
+func checkPixels(t *testing.T, img image.Image, want [][]color.NRGBA) {
+	t.Helper()
+	b := img.Bounds()
+	if b.Dx() != len(want[0]) || b.Dy() != len(want) {
+		t.Fatalf("decoded %dx%d, want %dx%d", b.Dx(), b.Dy(), len(want[0]), len(want))
+	}
+	for y, row := range want {
+		for x, c := range row {
+			got := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			if got.A == 0 && c.A == 0 {
+				continue
+			}
+			if got != c {
+				t.Errorf("pixel %d,%d = %v, want %v", x, y, got, c)
+			}
+		}
+	}
+}
+
+// errorCase is a file that must fail to decode with an error containing want.
+type errorCase struct {
+	name string
+	data []byte
+	want string
+}
+
+func decodeErrors(t *testing.T, decode func([]byte) error, tests []errorCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decode(tt.data)
+			if err == nil {
+				t.Fatal("decoded without an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// truncations checks that every prefix of a valid file fails cleanly,
+// without panicking, except the last slack bytes that are optional.
+func truncations(t *testing.T, decode func([]byte) error, data []byte, slack int) {
+	t.Helper()
+	for n := 0; n < len(data)-slack; n++ {
+		if err := decode(data[:n]); err == nil {
+			t.Errorf("decoded the first %d of %d bytes without an error", n, len(data))
+		}
+	}
+}
+
+// allocated returns how many bytes fn allocates.
+func allocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+// shortFileLimit is how much a decoder may allocate for a header claiming
+// a huge image followed by almost no data.
+const shortFileLimit = 16 << 20
+
+func TestCheckSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		ok            bool
+	}{
+		{1, 1, true},
+		{8192, 8192, true},
+		{maxPixels, 1, true},
+		{0, 10, false},
+		{10, -1, false},
+		{8193, 8192, false},
+		{1 << 31, 1 << 31, false},
+	}
+	for _, tt := range tests {
+		if err := checkSize("test", tt.width, tt.height); (err == nil) != tt.ok {
+			t.Errorf("checkSize(%d, %d) = %v, want ok %v", tt.width, tt.height, err, tt.ok)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		v, max uint32
+		want8  uint8
+		want16 uint16
+	}{
+		{0, 255, 0, 0},
+		{255, 255, 255, 65535},
+		{1, 1, 255, 65535},
+		{15, 31, 123, 31710},
+		{1000, 1000, 255, 65535},
+		{2000, 1000, 255, 65535},
+		{128, 65535, 0, 128},
+		{5, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := scaleTo8(tt.v, tt.max); got != tt.want8 {
+			t.Errorf("scaleTo8(%d, %d) = %d, want %d", tt.v, tt.max, got, tt.want8)
+		}
+		if got := scaleTo16(tt.v, tt.max); got != tt.want16 {
+			t.Errorf("scaleTo16(%d, %d) = %d, want %d", tt.v, tt.max, got, tt.want16)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"strings"
+)
+
+// ErrNoEXIF is returned by DecodeJPEGEXIF for a JPEG without EXIF metadata.
+var ErrNoEXIF = errors.New("codec: no EXIF metadata")
+
+// EXIF is the part of a JPEG's EXIF metadata worth showing: how the camera
+// was held, and what took the picture. Strings are empty when not tagged.
+type EXIF struct {
+	Orientation  int // 1 to 8 as defined by EXIF, 1 is upright
+	Make         string
+	Model        string
+	DateTime     string // when it was taken, or last changed if that's all there is
+	ExposureTime string // like "1/120"
+	FNumber      string // like "f/1.8"
+	ISO          int
+	FocalLength  string // like "4.2mm"
+}
+
+const (
+	exifOrientation      = 0x0112
+	exifMake             = 0x010f
+	exifModel            = 0x0110
+	exifDateTime         = 0x0132
+	exifSubIFD           = 0x8769
+	exifExposureTime     = 0x829a
+	exifFNumber          = 0x829d
+	exifISO              = 0x8827
+	exifDateTimeOriginal = 0x9003
+	exifFocalLength      = 0x920a
+)
+
+// Tags returns the tagged fields by name, for logs and metadata output.
+func (e *EXIF) Tags() map[string]string {
+	tags := map[string]string{"orientation": fmt.Sprint(e.Orientation)}
+	for name, value := range map[string]string{
+		"make":          e.Make,
+		"model":         e.Model,
+		"date_time":     e.DateTime,
+		"exposure_time": e.ExposureTime,
+		"f_number":      e.FNumber,
+		"focal_length":  e.FocalLength,
+	} {
+		if value != "" {
+			tags[name] = value
+		}
+	}
+	if e.ISO > 0 {
+		tags["iso"] = fmt.Sprint(e.ISO)
+	}
+	return tags
+}
+
+// DecodeJPEGEXIF reads the EXIF metadata of a JPEG stream. It reads only up
+// to the start of the image data, JPEG writers put metadata before it.
+func DecodeJPEGEXIF(r io.Reader) (*EXIF, error) {
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if err := readFull("exif", br, soi[:]); err != nil {
+		return nil, err
+	}
+	if soi[0] != 0xff || soi[1] != 0xd8 {
+		return nil, fmt.Errorf("exif: not a JPEG")
+	}
+
+	for {
+		marker, err := nextJPEGMarker(br)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case marker == 0xda || marker == 0xd9: // start of scan, end of image
+			return nil, ErrNoEXIF
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			continue // standalone, no length
+		}
+
+		var length [2]byte
+		if err := readFull("exif", br, length[:]); err != nil {
+			return nil, err
+		}
+		n := int(binary.BigEndian.Uint16(length[:])) - 2
+		if n < 0 {
+			return nil, fmt.Errorf("exif: invalid segment length")
+		}
+		if marker != 0xe1 {
+			if _, err := br.Discard(n); err != nil {
+				return nil, fmt.Errorf("exif: truncated image")
+			}
+			continue
+		}
+
+		// APP1 also carries XMP, EXIF is the one with this header
+		segment := make([]byte, n)
+		if err := readFull("exif", br, segment); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(string(segment), "Exif\x00\x00") {
+			return parseTIFFEXIF(segment[6:])
+		}
+	}
+}
+
+// nextJPEGMarker skips to the next marker and returns its code.
+func nextJPEGMarker(br *bufio.Reader) (byte, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("exif: truncated image")
+	}
+	if b != 0xff {
+		return 0, fmt.Errorf("exif: expected a marker")
+	}
+	// Any number of 0xff may pad before the code
+	for b == 0xff {
+		if b, err = br.ReadByte(); err != nil {
+			return 0, fmt.Errorf("exif: truncated image")
+		}
+	}
+	return b, nil
+}
+
+// tiffReader reads values out of the TIFF structure EXIF is stored in,
+// checking every offset against the data.
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func (t *tiffReader) u16(off int) (uint16, bool) {
+	if off < 0 || off+2 > len(t.data) {
+		return 0, false
+	}
+	return t.order.Uint16(t.data[off:]), true
+}
+
+func (t *tiffReader) u32(off int) (uint32, bool) {
+	if off < 0 || off+4 > len(t.data) {
+		return 0, false
+	}
+	return t.order.Uint32(t.data[off:]), true
+}
+
+// tiffEntry is one IFD entry. At is where its 4 byte value field is, which
+// holds the value itself when it fits and its offset otherwise.
+type tiffEntry struct {
+	tag, kind uint16
+	count     uint32
+	at        int
+}
+
+var tiffTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// value returns the bytes of the entry's value.
+func (t *tiffReader) value(e tiffEntry) ([]byte, bool) {
+	size, known := tiffTypeSizes[e.kind]
+	if !known || e.count > 1<<20 {
+		return nil, false
+	}
+	n := size * int(e.count)
+	off := e.at
+	if n > 4 {
+		v, ok := t.u32(e.at)
+		if !ok {
+			return nil, false
+		}
+		off = int(v)
+	}
+	if off < 0 || off+n > len(t.data) {
+		return nil, false
+	}
+	return t.data[off : off+n], true
+}
+
+func (t *tiffReader) ifd(off int) []tiffEntry {
+	count, ok := t.u16(off)
+	if !ok {
+		return nil
+	}
+	var entries []tiffEntry
+	for i := 0; i < int(count); i++ {
+		at := off + 2 + i*12
+		tag, ok1 := t.u16(at)
+		kind, ok2 := t.u16(at + 2)
+		n, ok3 := t.u32(at + 4)
+		if !ok1 || !ok2 || !ok3 {
+			break
+		}
+		entries = append(entries, tiffEntry{tag: tag, kind: kind, count: n, at: at + 8})
+	}
+	return entries
+}
+
+func (t *tiffReader) ascii(e tiffEntry) string {
+	v, ok := t.value(e)
+	if !ok || e.kind != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(v), "\x00"))
+}
+
+func (t *tiffReader) uint(e tiffEntry) (int, bool) {
+	v, ok := t.value(e)
+	if !ok || len(v) == 0 {
+		return 0, false
+	}
+	switch e.kind {
+	case 3:
+		return int(t.order.Uint16(v)), true
+	case 4:
+		return int(t.order.Uint32(v)), true
+	}
+	return 0, false
+}
+
+func (t *tiffReader) rational(e tiffEntry) (num, den uint32, ok bool) {
+	v, ok := t.value(e)
+	if !ok || e.kind != 5 || len(v) < 8 {
+		return 0, 0, false
+	}
+	num, den = t.order.Uint32(v), t.order.Uint32(v[4:])
+	return num, den, den != 0
+}
+
+func parseTIFFEXIF(data []byte) (*EXIF, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("exif: truncated metadata")
+	}
+	t := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("exif: invalid byte order")
+	}
+	if magic, _ := t.u16(2); magic != 42 {
+		return nil, fmt.Errorf("exif: invalid header")
+	}
+	ifd0, _ := t.u32(4)
+
+	exif := &EXIF{Orientation: 1}
+	var subIFD int
+	for _, e := range t.ifd(int(ifd0)) {
+		switch e.tag {
+		case exifOrientation:
+			if v, ok := t.uint(e); ok && v >= 1 && v <= 8 {
+				exif.Orientation = v
+			}
+		case exifMake:
+			exif.Make = t.ascii(e)
+		case exifModel:
+			exif.Model = t.ascii(e)
+		case exifDateTime:
+			exif.DateTime = t.ascii(e)
+		case exifSubIFD:
+			subIFD, _ = t.uint(e)
+		}
+	}
+	if subIFD == 0 {
+		return exif, nil
+	}
+
+	for _, e := range t.ifd(subIFD) {
+		switch e.tag {
+		case exifDateTimeOriginal:
+			if v := t.ascii(e); v != "" {
+				exif.DateTime = v
+			}
+		case exifISO:
+			exif.ISO, _ = t.uint(e)
+		case exifExposureTime:
+			if num, den, ok := t.rational(e); ok && num > 0 {
+				if num < den {
+					exif.ExposureTime = fmt.Sprintf("1/%d", (den+num/2)/num)
+				} else {
+					exif.ExposureTime = fmt.Sprintf("%gs", float64(num)/float64(den))
+				}
+			}
+		case exifFNumber:
+			if num, den, ok := t.rational(e); ok {
+				exif.FNumber = fmt.Sprintf("f/%.1f", float64(num)/float64(den))
+			}
+		case exifFocalLength:
+			if num, den, ok := t.rational(e); ok {
+				exif.FocalLength = fmt.Sprintf("%gmm", float64(num)/float64(den))
+			}
+		}
+	}
+	return exif, nil
+}
+
+// Orient turns img upright according to an EXIF orientation: 2 and 4 are
+// mirrored, 3 is upside down, 5 to 8 are on their side. Orientation 1 and
+// unknown values return img as it is, anything else a rotated or flipped
+// copy with its origin at 0,0.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	// Convert once with draw's fast paths, then move whole pixels around
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, h-1-y
+			case 5: // mirrored, on its left side
+				sx, sy = y, x
+			case 6: // on its left side, turn clockwise
+				sx, sy = y, h-1-x
+			case 7: // mirrored, on its right side
+				sx, sy = w-1-y, h-1-x
+			case 8: // on its right side, turn counterclockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,379 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// exifEntry is one IFD entry for tiffFile, with its value already encoded.
+type exifEntry struct {
+	tag, kind uint16
+	count     uint32
+	value     []byte
+}
+
+// tiffBuilder encodes TIFF structures in one byte order.
+type tiffBuilder struct {
+	order interface {
+		binary.ByteOrder
+		binary.AppendByteOrder
+	}
+}
+
+func (b tiffBuilder) short(tag uint16, v uint16) exifEntry {
+	return exifEntry{tag, 3, 1, b.order.AppendUint16(nil, v)}
+}
+
+func (b tiffBuilder) long(tag uint16, v uint32) exifEntry {
+	return exifEntry{tag, 4, 1, b.order.AppendUint32(nil, v)}
+}
+
+func (b tiffBuilder) ascii(tag uint16, s string) exifEntry {
+	return exifEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func (b tiffBuilder) rational(tag uint16, num, den uint32) exifEntry {
+	return exifEntry{tag, 5, 1, b.order.AppendUint32(b.order.AppendUint32(nil, num), den)}
+}
+
+// file lays out a TIFF header, IFD0, the EXIF sub-IFD when there are entries
+// for it, then the values too long to fit in their entries.
+func (b tiffBuilder) file(ifd0, sub []exifEntry) []byte {
+	ifdSize := func(entries []exifEntry) int { return 2 + 12*len(entries) + 4 }
+	if len(sub) > 0 {
+		ifd0 = append(ifd0, exifEntry{tag: exifSubIFD})
+	}
+	subAt := 8 + ifdSize(ifd0)
+	valuesAt := subAt
+	if len(sub) > 0 {
+		valuesAt += ifdSize(sub)
+	}
+
+	data := []byte("II")
+	if b.order == binary.BigEndian {
+		data = []byte("MM")
+	}
+	data = b.order.AppendUint16(data, 42)
+	data = b.order.AppendUint32(data, 8)
+
+	var values []byte
+	ifd := func(entries []exifEntry) {
+		data = b.order.AppendUint16(data, uint16(len(entries)))
+		for _, e := range entries {
+			if e.tag == exifSubIFD {
+				e = b.long(exifSubIFD, uint32(subAt))
+			}
+			data = b.order.AppendUint16(data, e.tag)
+			data = b.order.AppendUint16(data, e.kind)
+			data = b.order.AppendUint32(data, e.count)
+			if len(e.value) > 4 {
+				data = b.order.AppendUint32(data, uint32(valuesAt+len(values)))
+				values = append(values, e.value...)
+			} else {
+				data = append(data, e.value...)
+				data = append(data, make([]byte, 4-len(e.value))...)
+			}
+		}
+		data = b.order.AppendUint32(data, 0) // no next IFD
+	}
+	ifd(ifd0)
+	if len(sub) > 0 {
+		ifd(sub)
+	}
+	return append(data, values...)
+}
+
+// jpegSegment encodes a marker segment with its length.
+func jpegSegment(marker byte, payload []byte) []byte {
+	data := []byte{0xff, marker}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(payload)+2))
+	return append(data, payload...)
+}
+
+// jpegFile is SOI, the segments, then the start of the image data.
+func jpegFile(segments ...[]byte) []byte {
+	data := []byte{0xff, 0xd8}
+	for _, s := range segments {
+		data = append(data, s...)
+	}
+	return append(data, jpegSegment(0xda, []byte{1, 1, 0, 0, 63, 0})...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return jpegSegment(0xe1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+var (
+	jfifSegment = jpegSegment(0xe0, []byte("JFIF\x00\x01\x02\x00\x00\x01\x00\x01\x00\x00"))
+	xmpSegment  = jpegSegment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>"))
+)
+
+func TestDecodeJPEGEXIF(t *testing.T) {
+	for _, b := range []tiffBuilder{{binary.LittleEndian}, {binary.BigEndian}} {
+		t.Run(b.order.String(), func(t *testing.T) {
+			tiff := b.file([]exifEntry{
+				b.ascii(exifMake, "Foo"), // fits in the entry
+				b.ascii(exifModel, "Foo Phone 7 Pro  "),
+				b.short(exifOrientation, 6),
+				b.ascii(exifDateTime, "2024:01:02 03:04:05"),
+			}, []exifEntry{
+				b.rational(exifExposureTime, 10, 1200),
+				b.rational(exifFNumber, 18, 10),
+				b.short(exifISO, 100),
+				b.ascii(exifDateTimeOriginal, "2023:12:24 18:30:00"),
+				b.rational(exifFocalLength, 42, 10),
+			})
+			data := jpegFile(
+				jfifSegment,
+				[]byte{0xff, 0xff}, // fill bytes before the next marker
+				xmpSegment,
+				exifSegment(tiff),
+			)
+
+			got, err := DecodeJPEGEXIF(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &EXIF{
+				Orientation:  6,
+				Make:         "Foo",
+				Model:        "Foo Phone 7 Pro",
+				DateTime:     "2023:12:24 18:30:00",
+				ExposureTime: "1/120",
+				FNumber:      "f/1.8",
+				ISO:          100,
+				FocalLength:  "4.2mm",
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseTIFFEXIFValues(t *testing.T) {
+	b := tiffBuilder{binary.BigEndian}
+	tests := []struct {
+		name      string
+		ifd0, sub []exifEntry
+		want      EXIF
+	}{
+		{"nothing tagged", nil, nil, EXIF{Orientation: 1}},
+		{"orientation as a long", []exifEntry{b.long(exifOrientation, 8)}, nil, EXIF{Orientation: 8}},
+		{"orientation out of range", []exifEntry{b.short(exifOrientation, 9)}, nil, EXIF{Orientation: 1}},
+		{"orientation as text", []exifEntry{b.ascii(exifOrientation, "6")}, nil, EXIF{Orientation: 1}},
+		{"make as a number", []exifEntry{b.short(exifMake, 1)}, nil, EXIF{Orientation: 1}},
+		{"date only in IFD0", []exifEntry{b.ascii(exifDateTime, "2020:02:02 02:02:02")}, []exifEntry{b.short(exifISO, 50)},
+			EXIF{Orientation: 1, DateTime: "2020:02:02 02:02:02", ISO: 50}},
+		{"long exposure", nil, []exifEntry{b.rational(exifExposureTime, 5, 2)}, EXIF{Orientation: 1, ExposureTime: "2.5s"}},
+		{"zero exposure", nil, []exifEntry{b.rational(exifExposureTime, 0, 1)}, EXIF{Orientation: 1}},
+		{"zero denominator", nil, []exifEntry{b.rational(exifFNumber, 18, 0), b.rational(exifFocalLength, 1, 0)}, EXIF{Orientation: 1}},
+		{"ISO as a long", nil, []exifEntry{b.long(exifISO, 3200)}, EXIF{Orientation: 1, ISO: 3200}},
+		{"sub-IFD tags in IFD0", []exifEntry{b.short(exifISO, 100)}, nil, EXIF{Orientation: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTIFFEXIF(b.file(tt.ifd0, tt.sub))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJPEGEXIFErrors(t *testing.T) {
+	b := tiffBuilder{binary.LittleEndian}
+	valid := b.file([]exifEntry{b.short(exifOrientation, 3)}, nil)
+	withHeader := func(header string) []byte {
+		return append([]byte(header), valid[len(header):]...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "truncated"},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n"), "not a JPEG"},
+		{"ends after SOI", []byte{0xff, 0xd8}, "truncated"},
+		{"garbage instead of a marker", []byte{0xff, 0xd8, 0x12, 0x34}, "expected a marker"},
+		{"ends in fill bytes", []byte{0xff, 0xd8, 0xff, 0xff}, "truncated"},
+		{"ends before a length", []byte{0xff, 0xd8, 0xff, 0xe0, 0x00}, "truncated"},
+		{"segment length below 2", []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x01}, "invalid segment length"},
+		{"short segment", append([]byte{0xff, 0xd8}, jpegSegment(0xe0, make([]byte, 20))[:10]...), "truncated"},
+		{"short EXIF segment", append([]byte{0xff, 0xd8}, exifSegment(valid)[:20]...), "truncated"},
+		{"EXIF shorter than its header", jpegFile(exifSegment(valid[:6])), "truncated metadata"},
+		{"bad byte order", jpegFile(exifSegment(withHeader("XX"))), "byte order"},
+		{"mixed byte order", jpegFile(exifSegment(withHeader("IM"))), "byte order"},
+		{"bad magic number", jpegFile(exifSegment(withHeader("II\x2b\x00"))), "invalid header"},
+		{"big-endian magic in a little-endian file", jpegFile(exifSegment(withHeader("II\x00\x2a"))), "invalid header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeJPEGEXIF(bytes.NewReader(tt.data))
+			if err == nil || errors.Is(err, ErrNoEXIF) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJPEGEXIFNoEXIF(t *testing.T) {
+	tests := map[string][]byte{
+		"no metadata":            jpegFile(),
+		"JFIF only":              jpegFile(jfifSegment),
+		"XMP only":               jpegFile(xmpSegment),
+		"standalone markers":     jpegFile([]byte{0xff, 0x01, 0xff, 0xd0}, jfifSegment),
+		"ends before image data": {0xff, 0xd8, 0xff, 0xd9},
+		// Metadata after the image data isn't looked for
+		"EXIF after the scan": append(jpegFile(), exifSegment(tiffBuilder{binary.LittleEndian}.file(nil, nil))...),
+	}
+	for name, data := range tests {
+		if _, err := DecodeJPEGEXIF(bytes.NewReader(data)); !errors.Is(err, ErrNoEXIF) {
+			t.Errorf("%s: got %v, want ErrNoEXIF", name, err)
+		}
+	}
+}
+
+func TestParseTIFFEXIFMalformed(t *testing.T) {
+	b := tiffBuilder{binary.LittleEndian}
+	le := binary.LittleEndian
+	tiff := b.file([]exifEntry{
+		b.short(exifOrientation, 6),
+		b.ascii(exifModel, "A long model name"),
+	}, []exifEntry{
+		b.rational(exifFNumber, 28, 10),
+		b.short(exifISO, 400),
+	})
+	patched := func(off int, v uint32) []byte {
+		data := append([]byte(nil), tiff...)
+		le.PutUint32(data[off:], v)
+		return data
+	}
+	// IFD0 is at 8, with its entries 12 bytes each from 10. The last one
+	// points to the sub-IFD.
+	entry := func(i int) int { return 10 + i*12 }
+	model := "A long model name"
+
+	tests := []struct {
+		name string
+		data []byte
+		want EXIF
+	}{
+		{"IFD0 past the end", patched(4, 1<<20), EXIF{Orientation: 1}},
+		{"IFD0 offset overflowing", patched(4, 1<<32-1), EXIF{Orientation: 1}},
+		// 255 entries claimed, one there
+		{"entry count past the end", append(tiff[:8:8], 0xff, 0, 0x12, 0x01, 3, 0, 1, 0, 0, 0, 6, 0, 0, 0), EXIF{Orientation: 6}},
+		{"value offset past the end", patched(entry(1)+8, 1<<20), EXIF{Orientation: 6, FNumber: "f/2.8", ISO: 400}},
+		{"value count overflowing", patched(entry(1)+4, 1<<31), EXIF{Orientation: 6, FNumber: "f/2.8", ISO: 400}},
+		{"unknown value type", patched(entry(0)+2, 0x63), EXIF{Orientation: 1, Model: model, FNumber: "f/2.8", ISO: 400}},
+		{"sub-IFD past the end", patched(entry(2)+8, 1<<20), EXIF{Orientation: 6, Model: model}},
+		{"sub-IFD pointing at IFD0", patched(entry(2)+8, 8), EXIF{Orientation: 6, Model: model}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTIFFEXIF(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	// Cut anywhere, the metadata reads without panicking, and the segment
+	// around it fails cleanly
+	for n := 0; n <= len(tiff); n++ {
+		parseTIFFEXIF(tiff[:n])
+	}
+	data := jpegFile(exifSegment(tiff))
+	for n := 0; n < len(data); n++ {
+		DecodeJPEGEXIF(bytes.NewReader(data[:n]))
+	}
+}
+
+func TestEXIFTags(t *testing.T) {
+	e := &EXIF{Orientation: 6, Model: "Foo Phone", ISO: 200, FNumber: "f/2.0"}
+	want := map[string]string{"orientation": "6", "model": "Foo Phone", "iso": "200", "f_number": "f/2.0"}
+	if got := e.Tags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// layoutImage draws a layout like "ab/cd/ef", one gray pixel per letter and
+// one row per slash separated part.
+func layoutImage(layout string) *image.Gray {
+	rows := strings.Split(layout, "/")
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x := range row {
+			img.SetGray(x, y, color.Gray{row[x]})
+		}
+	}
+	return img
+}
+
+func imageLayout(img image.Image) string {
+	b := img.Bounds()
+	var rows []string
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		var row []byte
+		for x := b.Min.X; x < b.Max.X; x++ {
+			row = append(row, color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+		rows = append(rows, string(row))
+	}
+	return strings.Join(rows, "/")
+}
+
+func TestOrient(t *testing.T) {
+	// How a camera stores the upright picture "ab/cd/ef" under each
+	// orientation, from the EXIF specification
+	const upright = "ab/cd/ef"
+	stored := map[int]string{
+		1: "ab/cd/ef",
+		2: "ba/dc/fe",
+		3: "fe/dc/ba",
+		4: "ef/cd/ab",
+		5: "ace/bdf",
+		6: "bdf/ace",
+		7: "fdb/eca",
+		8: "eca/fdb",
+	}
+	for orientation := 1; orientation <= 8; orientation++ {
+		img := layoutImage(stored[orientation])
+		if got := imageLayout(Orient(img, orientation)); got != upright {
+			t.Errorf("orientation %d turned %s into %s, want %s", orientation, stored[orientation], got, upright)
+		}
+
+		// The origin of the source doesn't matter, the result starts at 0,0
+		b := img.Bounds()
+		framed := image.NewGray(b.Inset(-1))
+		draw.Draw(framed, b, img, b.Min, draw.Src)
+		got := Orient(framed.SubImage(b), orientation)
+		if orientation > 1 && got.Bounds().Min != (image.Point{}) {
+			t.Errorf("orientation %d: result starts at %v", orientation, got.Bounds().Min)
+		}
+		if layout := imageLayout(got); layout != upright {
+			t.Errorf("orientation %d of a sub-image: got %s, want %s", orientation, layout, upright)
+		}
+	}
+
+	img := layoutImage(upright)
+	for _, orientation := range []int{0, 1, 9, -1} {
+		if Orient(img, orientation) != image.Image(img) {
+			t.Errorf("orientation %d changed the image", orientation)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package codec
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+)
+
+// farbfeld is "farbfeld", width and height as big-endian uint32, then every
+// pixel as four big-endian uint16 RGBA samples, not premultiplied.
+const farbfeldMagic = "farbfeld"
+
+func init() {
+	image.RegisterFormat("farbfeld", farbfeldMagic, decodeFarbfeld, decodeFarbfeldConfig)
+}
+
+func readFarbfeldHeader(r io.Reader) (width, height int, err error) {
+	var header [16]byte
+	if err := readFull("farbfeld", r, header[:]); err != nil {
+		return 0, 0, err
+	}
+	if string(header[:8]) != farbfeldMagic {
+		return 0, 0, image.ErrFormat
+	}
+	width = int(binary.BigEndian.Uint32(header[8:]))
+	height = int(binary.BigEndian.Uint32(header[12:]))
+	return width, height, checkSize("farbfeld", width, height)
+}
+
+func decodeFarbfeldConfig(r io.Reader) (image.Config, error) {
+	width, height, err := readFarbfeldHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBA64Model, Width: width, Height: height}, nil
+}
+
+func decodeFarbfeld(r io.Reader) (image.Image, error) {
+	width, height, err := readFarbfeldHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The samples are stored exactly as NRGBA64 keeps them in memory, so the
+	// data becomes the image as is
+	pix, err := readData("farbfeld", r, width*height*8)
+	if err != nil {
+		return nil, err
+	}
+	return &image.NRGBA64{Pix: pix, Stride: width * 8, Rect: image.Rect(0, 0, width, height)}, nil
+}
@@ -0,0 +1,66 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// farbfeldFile builds a farbfeld file from RGBA samples.
+func farbfeldFile(width, height int, samples ...uint16) []byte {
+	data := []byte(farbfeldMagic)
+	data = binary.BigEndian.AppendUint32(data, uint32(width))
+	data = binary.BigEndian.AppendUint32(data, uint32(height))
+	for _, v := range samples {
+		data = binary.BigEndian.AppendUint16(data, v)
+	}
+	return data
+}
+
+func decodeFarbfeldBytes(data []byte) error {
+	_, err := decodeFarbfeld(bytes.NewReader(data))
+	return err
+}
+
+func TestFarbfeld(t *testing.T) {
+	var samples []uint16
+	for _, row := range ref {
+		for _, c := range row {
+			samples = append(samples, uint16(c.R)*0x101, uint16(c.G)*0x101, uint16(c.B)*0x101, 0xffff)
+		}
+	}
+	decodeCases(t, []codecCase{
+		{"3x2", farbfeldFile(3, 2, samples...), "farbfeld", ref},
+	})
+
+	// Samples keep all 16 bits and stay unpremultiplied
+	img, err := decodeFarbfeld(bytes.NewReader(farbfeldFile(2, 1, 0x1234, 0x5678, 0x9abc, 0x8000, 0xffff, 0, 0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.NRGBA64{{0x1234, 0x5678, 0x9abc, 0x8000}, {0xffff, 0, 0, 0}}
+	for x, c := range want {
+		if got := img.At(x, 0); got != c {
+			t.Errorf("pixel %d = %v, want %v", x, got, c)
+		}
+	}
+}
+
+func TestFarbfeldErrors(t *testing.T) {
+	decodeErrors(t, decodeFarbfeldBytes, []errorCase{
+		{"short header", []byte("farbfeld\x00\x00"), "truncated"},
+		{"short data", farbfeldFile(2, 2, 1, 2, 3, 4), "truncated"},
+		{"zero width", farbfeldFile(0, 2), "invalid dimensions"},
+		{"oversized header", farbfeldFile(1<<16, 1<<16), "too large"},
+		{"huge header", farbfeldFile(1<<32-1, 1<<32-1), "too large"},
+	})
+	truncations(t, decodeFarbfeldBytes, farbfeldFile(1, 1, 1, 2, 3, 4), 0)
+}
+
+func TestFarbfeldShortFileAllocation(t *testing.T) {
+	data := farbfeldFile(8192, 8192, 1, 2, 3, 4)
+	if n := allocated(func() { decodeFarbfeldBytes(data) }); n > shortFileLimit {
+		t.Errorf("a %d byte file allocated %d bytes", len(data), n)
+	}
+}
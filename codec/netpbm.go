@@ -0,0 +1,287 @@
+package codec
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Netpbm: "P1" to "P6", then width, height and, except for bitmaps, the
+// maximum sample value as ASCII numbers separated by whitespace and # comments.
+// P1-P3 store samples as ASCII numbers, P4-P6 in binary after exactly one
+// whitespace byte. Bitmaps are 1 for black, the others count up to white.
+func init() {
+	image.RegisterFormat("pbm", "P1", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("pgm", "P2", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("ppm", "P3", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("pbm", "P4", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("pgm", "P5", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("ppm", "P6", decodeNetpbm, decodeNetpbmConfig)
+}
+
+type netpbmHeader struct {
+	kind     byte // '1' to '6'
+	width    int
+	height   int
+	maxval   uint32
+	channels int
+}
+
+func (h *netpbmHeader) plain() bool  { return h.kind <= '3' }
+func (h *netpbmHeader) bitmap() bool { return h.kind == '1' || h.kind == '4' }
+
+func (h *netpbmHeader) colorModel() color.Model {
+	switch {
+	case h.channels == 3 && h.maxval > 255:
+		return color.RGBA64Model
+	case h.channels == 3:
+		return color.RGBAModel
+	case h.maxval > 255:
+		return color.Gray16Model
+	}
+	return color.GrayModel
+}
+
+func readNetpbmHeader(br *bufio.Reader) (*netpbmHeader, error) {
+	var magic [2]byte
+	if err := readFull("netpbm", br, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic[0] != 'P' || magic[1] < '1' || magic[1] > '6' {
+		return nil, image.ErrFormat
+	}
+
+	h := &netpbmHeader{kind: magic[1], maxval: 1, channels: 1}
+	if h.kind == '3' || h.kind == '6' {
+		h.channels = 3
+	}
+	width, err := readNetpbmNumber(br)
+	if err != nil {
+		return nil, err
+	}
+	height, err := readNetpbmNumber(br)
+	if err != nil {
+		return nil, err
+	}
+	h.width, h.height = int(width), int(height)
+	if err := checkSize("netpbm", h.width, h.height); err != nil {
+		return nil, err
+	}
+	if !h.bitmap() {
+		if h.maxval, err = readNetpbmNumber(br); err != nil {
+			return nil, err
+		}
+		if h.maxval == 0 || h.maxval > 65535 {
+			return nil, fmt.Errorf("netpbm: invalid maximum value %d", h.maxval)
+		}
+	}
+
+	// Binary samples start right after the single whitespace byte that ends
+	// the header
+	if !h.plain() {
+		if b, err := br.ReadByte(); err != nil || !isNetpbmSpace(b) {
+			return nil, fmt.Errorf("netpbm: malformed header")
+		}
+	}
+	return h, nil
+}
+
+func isNetpbmSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// skipNetpbmSpace skips whitespace and comments, which run to the end of the
+// line.
+func skipNetpbmSpace(br *bufio.Reader) error {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == '#':
+			if _, err := br.ReadString('\n'); err != nil {
+				return err
+			}
+		case !isNetpbmSpace(b):
+			return br.UnreadByte()
+		}
+	}
+}
+
+// readNetpbmNumber reads one ASCII decimal number, skipping what comes
+// before it.
+func readNetpbmNumber(br *bufio.Reader) (uint32, error) {
+	if err := skipNetpbmSpace(br); err != nil {
+		return 0, fmt.Errorf("netpbm: truncated image")
+	}
+	var n uint64
+	digits := 0
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if b < '0' || b > '9' {
+			br.UnreadByte()
+			break
+		}
+		n = n*10 + uint64(b-'0')
+		if n > 1<<31 {
+			return 0, fmt.Errorf("netpbm: number out of range")
+		}
+		digits++
+	}
+	if digits == 0 {
+		return 0, fmt.Errorf("netpbm: expected a number")
+	}
+	return uint32(n), nil
+}
+
+// readPlainBit reads one P1 sample. Bits need no separator, "0110" is four.
+func readPlainBit(br *bufio.Reader) (uint32, error) {
+	if err := skipNetpbmSpace(br); err != nil {
+		return 0, fmt.Errorf("netpbm: truncated image")
+	}
+	b, _ := br.ReadByte()
+	if b != '0' && b != '1' {
+		return 0, fmt.Errorf("netpbm: invalid bitmap sample %q", b)
+	}
+	return uint32(b - '0'), nil
+}
+
+func decodeNetpbmConfig(r io.Reader) (image.Config, error) {
+	h, err := readNetpbmHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: h.colorModel(), Width: h.width, Height: h.height}, nil
+}
+
+func decodeNetpbm(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readNetpbmHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if h.kind == '4' {
+		return decodePBMRaw(br, h)
+	}
+
+	// Every other variant is a sequence of samples, only how each is read
+	// differs. They are all read before the image is allocated, so a short
+	// file claiming a huge image fails without allocating for it.
+	count := h.width * h.height * h.channels
+	var sample func() uint32
+	if h.plain() {
+		read := readNetpbmNumber
+		if h.kind == '1' {
+			read = readPlainBit
+		}
+		// Gathered as they parse, clamped to the maximum value as scaling
+		// would anyway
+		var samples []uint16
+		for len(samples) < count {
+			v, err := read(br)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, uint16(min(v, h.maxval)))
+		}
+		pos := 0
+		sample = func() uint32 {
+			pos++
+			return uint32(samples[pos-1])
+		}
+	} else {
+		// Binary samples are one byte, or two big-endian ones above 255
+		size := 1
+		if h.maxval > 255 {
+			size = 2
+		}
+		data, err := readData("netpbm", br, count*size)
+		if err != nil {
+			return nil, err
+		}
+		pos := 0
+		sample = func() uint32 {
+			v := uint32(data[pos])
+			if size == 2 {
+				v = v<<8 | uint32(data[pos+1])
+			}
+			pos += size
+			return v
+		}
+	}
+
+	bounds := image.Rect(0, 0, h.width, h.height)
+	wide := h.maxval > 255
+	var img image.Image
+	var set func(i int, v []uint32)
+	switch {
+	case h.channels == 3 && wide:
+		m := image.NewRGBA64(bounds)
+		img, set = m, func(i int, v []uint32) {
+			m.SetRGBA64(i%h.width, i/h.width, color.RGBA64{
+				R: scaleTo16(v[0], h.maxval), G: scaleTo16(v[1], h.maxval), B: scaleTo16(v[2], h.maxval), A: 0xffff,
+			})
+		}
+	case h.channels == 3:
+		m := image.NewRGBA(bounds)
+		img, set = m, func(i int, v []uint32) {
+			m.Pix[i*4+0] = scaleTo8(v[0], h.maxval)
+			m.Pix[i*4+1] = scaleTo8(v[1], h.maxval)
+			m.Pix[i*4+2] = scaleTo8(v[2], h.maxval)
+			m.Pix[i*4+3] = 0xff
+		}
+	case h.bitmap():
+		m := image.NewGray(bounds)
+		img, set = m, func(i int, v []uint32) {
+			if v[0] == 0 {
+				m.Pix[i] = 0xff
+			}
+		}
+	case wide:
+		m := image.NewGray16(bounds)
+		img, set = m, func(i int, v []uint32) {
+			m.SetGray16(i%h.width, i/h.width, color.Gray16{Y: scaleTo16(v[0], h.maxval)})
+		}
+	default:
+		m := image.NewGray(bounds)
+		img, set = m, func(i int, v []uint32) { m.Pix[i] = scaleTo8(v[0], h.maxval) }
+	}
+
+	values := make([]uint32, h.channels)
+	for i := 0; i < h.width*h.height; i++ {
+		for c := range values {
+			values[c] = sample()
+		}
+		set(i, values)
+	}
+	return img, nil
+}
+
+// decodePBMRaw reads a P4 bitmap, eight pixels per byte with the most
+// significant bit first and every row starting on a new byte.
+func decodePBMRaw(br *bufio.Reader, h *netpbmHeader) (image.Image, error) {
+	stride := (h.width + 7) / 8
+	data, err := readData("netpbm", br, stride*h.height)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+	for y := 0; y < h.height; y++ {
+		row := data[y*stride:]
+		for x := 0; x < h.width; x++ {
+			if row[x/8]&(0x80>>(x%8)) == 0 {
+				img.Pix[y*img.Stride+x] = 0xff
+			}
+		}
+	}
+	return img, nil
+}
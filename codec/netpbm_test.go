@@ -0,0 +1,103 @@
+package codec
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+// netpbmFile is a header followed by binary samples.
+func netpbmFile(header string, samples ...byte) []byte {
+	return append([]byte(header), samples...)
+}
+
+func decodeNetpbmBytes(data []byte) error {
+	_, err := decodeNetpbm(bytes.NewReader(data))
+	return err
+}
+
+var (
+	// A bitmap with 1 for black
+	bitmap = [][]color.NRGBA{
+		{black, white, black},
+		{white, white, black},
+	}
+	grays = [][]color.NRGBA{
+		{{0, 0, 0, 255}, {51, 51, 51, 255}, {102, 102, 102, 255}},
+		{{153, 153, 153, 255}, {204, 204, 204, 255}, {255, 255, 255, 255}},
+	}
+)
+
+func TestNetpbm(t *testing.T) {
+	decodeCases(t, []codecCase{
+		{"plain bitmap", []byte("P1\n# a comment\n3 2\n1 0 1\n001\n"), "pbm", bitmap},
+		{"plain bitmap without separators", []byte("P1 3 2 101001"), "pbm", bitmap},
+		{"plain graymap", []byte("P2\n3 2\n5\n0 1 2\n3 4 5\n"), "pgm", grays},
+		{"plain pixmap", []byte("P3 3 2 15 # maxval 15\n15 0 0  0 15 0  0 0 15\n15 15 15  0 0 0  8 8 8\n"), "ppm",
+			[][]color.NRGBA{{red, green, blue}, {white, black, {136, 136, 136, 255}}}},
+		{"binary bitmap", netpbmFile("P4\n3 2\n", 0xa0, 0x3f), "pbm", bitmap},
+		{"binary graymap", netpbmFile("P5 3 2 5\n", 0, 1, 2, 3, 4, 5), "pgm", grays},
+		{"binary 16-bit graymap", netpbmFile("P5\n3 2\n1000\n", 0, 0, 0, 200, 0x01, 0x90, 0x02, 0x58, 0x03, 0x20, 0x03, 0xe8), "pgm", grays},
+		{"binary pixmap", netpbmFile("P6\n#comment\n3 2\n255\n", 255, 0, 0, 0, 255, 0, 0, 0, 255, 255, 255, 255, 0, 0, 0, 128, 128, 128), "ppm", ref},
+		{"binary 16-bit pixmap", netpbmFile("P6 3 2 65535\n",
+			0xff, 0xff, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0xff, 0xff,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80), "ppm", ref},
+	})
+}
+
+// TestNetpbm16Bit checks samples above 255 keep their precision.
+func TestNetpbm16Bit(t *testing.T) {
+	img, err := decodeNetpbm(bytes.NewReader(netpbmFile("P5 2 1 1000\n", 0x01, 0xf4, 0x03, 0xe7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.Gray16{{32768}, {65469}}
+	for x, c := range want {
+		if got := img.At(x, 0); got != c {
+			t.Errorf("pixel %d = %v, want %v", x, got, c)
+		}
+	}
+}
+
+func TestNetpbmErrors(t *testing.T) {
+	decodeErrors(t, decodeNetpbmBytes, []errorCase{
+		{"zero maxval", []byte("P2 1 1 0\n0\n"), "maximum value"},
+		{"maxval above 16 bits", netpbmFile("P5 1 1 65536\n", 0, 0), "maximum value"},
+		{"zero width", []byte("P1 0 1\n"), "invalid dimensions"},
+		{"oversized header", []byte("P6 100000 100000 255\n"), "too large"},
+		{"number out of range", []byte("P2 99999999999 1 255\n"), "out of range"},
+		{"missing number", []byte("P2 3 x 255\n"), "expected a number"},
+		{"bad bitmap sample", []byte("P1 2 1 1 2\n"), "bitmap sample"},
+		{"no space after the header", []byte("P5 1 1 255x"), "malformed header"},
+		{"short plain data", []byte("P3 1 1 255 1 2\n"), "truncated"},
+		{"short binary data", netpbmFile("P6 2 1 255\n", 1, 2, 3, 4), "truncated"},
+		{"short 16-bit data", netpbmFile("P5 2 1 1000\n", 1, 2, 3), "truncated"},
+		{"short bitmap data", netpbmFile("P4 9 2\n", 0, 0, 0), "truncated"},
+	})
+
+	for _, data := range [][]byte{
+		[]byte("P1\n# c\n2 2\n1 0\n0 1"),
+		[]byte("P2 2 1 9\n3 4"),
+		netpbmFile("P4 9 2\n", 1, 2, 3, 4),
+		netpbmFile("P5 2 1 1000\n", 1, 2, 3, 4),
+		netpbmFile("P6 1 1 255\n", 1, 2, 3),
+	} {
+		truncations(t, decodeNetpbmBytes, data, 0)
+	}
+}
+
+func TestNetpbmShortFileAllocation(t *testing.T) {
+	for _, header := range []string{
+		"P1\n8192 8192\n",
+		"P2\n8192 8192\n255\n",
+		"P3\n8192 8192\n65535\n",
+		"P4 8192 8192\n",
+		"P5 8192 8192 255\n",
+		"P6 4096 4096 65535\n",
+	} {
+		data := netpbmFile(header, '1', '\n', '1', '\n')
+		if n := allocated(func() { decodeNetpbmBytes(data) }); n > shortFileLimit {
+			t.Errorf("%q with no data allocated %d bytes", header, n)
+		}
+	}
+}
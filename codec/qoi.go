@@ -0,0 +1,125 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// QOI, the "Quite OK Image" format: a 14 byte header, then a stream of ops
+// that each produce one or more pixels relative to the previous pixel or a
+// 64 entry cache of recently seen colors. See https://qoiformat.org.
+const qoiMagic = "qoif"
+
+const (
+	qoiOpIndex = 0x00 // 00xxxxxx
+	qoiOpDiff  = 0x40 // 01xxxxxx
+	qoiOpLuma  = 0x80 // 10xxxxxx
+	qoiOpRun   = 0xc0 // 11xxxxxx
+	qoiOpRGB   = 0xfe
+	qoiOpRGBA  = 0xff
+	qoiMask2   = 0xc0
+)
+
+func init() {
+	image.RegisterFormat("qoi", qoiMagic, decodeQOI, decodeQOIConfig)
+}
+
+func readQOIHeader(r io.Reader) (width, height int, err error) {
+	var header [14]byte
+	if err := readFull("qoi", r, header[:]); err != nil {
+		return 0, 0, err
+	}
+	if string(header[:4]) != qoiMagic {
+		return 0, 0, image.ErrFormat
+	}
+	width = int(binary.BigEndian.Uint32(header[4:]))
+	height = int(binary.BigEndian.Uint32(header[8:]))
+	if channels := header[12]; channels != 3 && channels != 4 {
+		return 0, 0, fmt.Errorf("qoi: invalid channel count %d", channels)
+	}
+	// header[13] says sRGB or linear, which doesn't change how pixels decode
+	return width, height, checkSize("qoi", width, height)
+}
+
+func decodeQOIConfig(r io.Reader) (image.Config, error) {
+	width, height, err := readQOIHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: width, Height: height}, nil
+}
+
+func decodeQOI(r io.Reader) (image.Image, error) {
+	width, height, err := readQOIHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	next := func() (byte, error) {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return 0, fmt.Errorf("qoi: truncated image")
+		}
+		return b, err
+	}
+
+	// The pixels are appended as they decode rather than allocated from the
+	// header, so a short file claiming a huge image runs out of ops first
+	size := width * height * 4
+	var pix []byte
+	var index [64]color.NRGBA
+	px := color.NRGBA{A: 0xff}
+	run := 0
+	for len(pix) < size {
+		if run > 0 {
+			run--
+		} else {
+			b1, err := next()
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case b1 == qoiOpRGB || b1 == qoiOpRGBA:
+				var buf [4]byte
+				n := 3
+				if b1 == qoiOpRGBA {
+					n = 4
+				}
+				if err := readFull("qoi", br, buf[:n]); err != nil {
+					return nil, err
+				}
+				px.R, px.G, px.B = buf[0], buf[1], buf[2]
+				if n == 4 {
+					px.A = buf[3]
+				}
+			case b1&qoiMask2 == qoiOpIndex:
+				px = index[b1]
+			case b1&qoiMask2 == qoiOpDiff:
+				px.R += (b1>>4)&0x03 - 2
+				px.G += (b1>>2)&0x03 - 2
+				px.B += b1&0x03 - 2
+			case b1&qoiMask2 == qoiOpLuma:
+				b2, err := next()
+				if err != nil {
+					return nil, err
+				}
+				dg := b1&0x3f - 32
+				px.R += dg - 8 + (b2>>4)&0x0f
+				px.G += dg
+				px.B += dg - 8 + b2&0x0f
+			default: // qoiOpRun
+				run = int(b1 & 0x3f)
+			}
+
+			index[(int(px.R)*3+int(px.G)*5+int(px.B)*7+int(px.A)*11)%64] = px
+		}
+
+		pix = append(pix, px.R, px.G, px.B, px.A)
+	}
+	return &image.NRGBA{Pix: pix, Stride: width * 4, Rect: image.Rect(0, 0, width, height)}, nil
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+var qoiEnd = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+// qoiFile builds a QOI file from a header and raw ops, with the end marker.
+func qoiFile(width, height int, channels byte, ops ...byte) []byte {
+	data := []byte(qoiMagic)
+	data = binary.BigEndian.AppendUint32(data, uint32(width))
+	data = binary.BigEndian.AppendUint32(data, uint32(height))
+	data = append(data, channels, 0)
+	data = append(data, ops...)
+	return append(data, qoiEnd...)
+}
+
+func decodeQOIBytes(data []byte) error {
+	_, err := decodeQOI(bytes.NewReader(data))
+	return err
+}
+
+func TestQOI(t *testing.T) {
+	decodeCases(t, []codecCase{
+		{"rgb", qoiFile(3, 2, 3,
+			qoiOpRGB, 255, 0, 0,
+			qoiOpRGB, 0, 255, 0,
+			qoiOpRGB, 0, 0, 255,
+			qoiOpRGB, 255, 255, 255,
+			qoiOpRGB, 0, 0, 0,
+			qoiOpRGB, 128, 128, 128,
+		), "qoi", ref},
+		{"rgba", qoiFile(3, 1, 4,
+			qoiOpRGBA, 255, 0, 0, 255,
+			qoiOpRGBA, 0, 255, 0, 128,
+			qoiOpRGB, 0, 0, 255, // keeps the alpha of the previous pixel
+		), "qoi", [][]color.NRGBA{{red, {0, 255, 0, 128}, {0, 0, 255, 128}}}},
+		{"index", qoiFile(3, 1, 3,
+			qoiOpRGB, 255, 0, 0,
+			qoiOpRGB, 0, 255, 0,
+			// (255*3 + 255*11) % 64 is 50
+			qoiOpIndex|50,
+		), "qoi", [][]color.NRGBA{{red, green, red}}},
+		{"diff and luma", qoiFile(3, 1, 3,
+			qoiOpRGB, 10, 20, 30,
+			// -1, 0 and +1
+			qoiOpDiff|1<<4|2<<2|3,
+			// Green -5, red -8 and blue +7 against it, so red wraps around
+			qoiOpLuma|27, 0x0f,
+		), "qoi", [][]color.NRGBA{{{10, 20, 30, 255}, {9, 20, 31, 255}, {252, 15, 33, 255}}}},
+		{"diff wraps", qoiFile(1, 1, 3,
+			qoiOpDiff|0<<4|3<<2|2,
+		), "qoi", [][]color.NRGBA{{{254, 1, 0, 255}}}},
+		{"runs across rows", qoiFile(3, 2, 3,
+			qoiOpRGB, 255, 0, 0,
+			qoiOpRun|3,
+			qoiOpRGB, 0, 0, 255,
+		), "qoi", [][]color.NRGBA{{red, red, red}, {red, red, blue}}},
+		{"starts from opaque black", qoiFile(2, 1, 4,
+			qoiOpRun|1,
+		), "qoi", [][]color.NRGBA{{black, black}}},
+	})
+}
+
+func TestQOIErrors(t *testing.T) {
+	valid := qoiFile(3, 1, 4, qoiOpRGBA, 1, 2, 3, 4, qoiOpLuma|32, 0x88, qoiOpIndex|5)
+	decodeErrors(t, decodeQOIBytes, []errorCase{
+		{"bad channel count", qoiFile(1, 1, 2, qoiOpRun), "channel count"},
+		{"zero height", qoiFile(1, 0, 3), "invalid dimensions"},
+		{"oversized header", qoiFile(1<<20, 1<<20, 3, qoiOpRun|61), "too large"},
+		{"short header", valid[:10], "truncated"},
+		{"ops run out", qoiFile(4, 4, 3, qoiOpRun|3)[:15], "truncated"},
+		{"short rgba op", valid[:17], "truncated"},
+		{"short luma op", valid[:20], "truncated"},
+	})
+	// The end marker isn't needed to decode
+	truncations(t, decodeQOIBytes, valid, len(qoiEnd))
+}
+
+func TestQOIShortFileAllocation(t *testing.T) {
+	data := qoiFile(8192, 8192, 4, qoiOpRun|61)
+	if n := allocated(func() { decodeQOIBytes(data) }); n > shortFileLimit {
+		t.Errorf("a %d byte file allocated %d bytes", len(data), n)
+	}
+}
@@ -0,0 +1,227 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// TGA has no magic number. The image type in its third byte, together with
+// the color map flag before it, is specific enough to sniff on; the first
+// byte is the length of the image ID and can be anything.
+func init() {
+	for _, magic := range []string{
+		"?\x00\x02", // truecolor
+		"?\x00\x03", // grayscale
+		"?\x01\x01", // color mapped
+		"?\x00\x0a", // run-length encoded truecolor
+		"?\x00\x0b", // run-length encoded grayscale
+		"?\x01\x09", // run-length encoded color mapped
+	} {
+		image.RegisterFormat("tga", magic, decodeTGA, decodeTGAConfig)
+	}
+}
+
+const (
+	tgaColorMapped = 1
+	tgaTrueColor   = 2
+	tgaGrayscale   = 3
+	tgaRLE         = 8 // added to the types above
+
+	tgaRightToLeft = 0x10
+	tgaTopToBottom = 0x20
+)
+
+type tgaHeader struct {
+	idLength     int
+	colorMapType int
+	imageType    int
+	mapFirst     int
+	mapLength    int
+	mapDepth     int
+	width        int
+	height       int
+	depth        int
+	descriptor   byte
+}
+
+func (h *tgaHeader) alphaBits() int { return int(h.descriptor & 0x0f) }
+
+func readTGAHeader(r io.Reader) (*tgaHeader, error) {
+	var b [18]byte
+	if err := readFull("tga", r, b[:]); err != nil {
+		return nil, err
+	}
+	h := &tgaHeader{
+		idLength:     int(b[0]),
+		colorMapType: int(b[1]),
+		imageType:    int(b[2]),
+		mapFirst:     int(binary.LittleEndian.Uint16(b[3:])),
+		mapLength:    int(binary.LittleEndian.Uint16(b[5:])),
+		mapDepth:     int(b[7]),
+		width:        int(binary.LittleEndian.Uint16(b[12:])),
+		height:       int(binary.LittleEndian.Uint16(b[14:])),
+		depth:        int(b[16]),
+		descriptor:   b[17],
+	}
+
+	valid := false
+	switch h.imageType &^ tgaRLE {
+	case tgaColorMapped:
+		valid = h.colorMapType == 1 && (h.depth == 8 || h.depth == 16) &&
+			(h.mapDepth == 15 || h.mapDepth == 16 || h.mapDepth == 24 || h.mapDepth == 32)
+	case tgaTrueColor:
+		valid = h.depth == 15 || h.depth == 16 || h.depth == 24 || h.depth == 32
+	case tgaGrayscale:
+		valid = h.depth == 8 || h.depth == 16
+	}
+	if !valid || h.colorMapType > 1 {
+		return nil, fmt.Errorf("tga: unsupported image type %d with %d bits per pixel", h.imageType, h.depth)
+	}
+	return h, checkSize("tga", h.width, h.height)
+}
+
+func decodeTGAConfig(r io.Reader) (image.Config, error) {
+	h, err := readTGAHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: h.width, Height: h.height}, nil
+}
+
+// tgaColor decodes one little-endian BGR(A) value of the given bit depth.
+// 16 bit values are 5 bits per channel with the top bit as alpha, which
+// only counts when the header says there is one alpha bit.
+func tgaColor(p []byte, depth, alphaBits int) color.NRGBA {
+	switch depth {
+	case 15, 16:
+		v := uint16(p[0]) | uint16(p[1])<<8
+		c := color.NRGBA{
+			R: scaleTo8(uint32(v>>10&0x1f), 0x1f),
+			G: scaleTo8(uint32(v>>5&0x1f), 0x1f),
+			B: scaleTo8(uint32(v&0x1f), 0x1f),
+			A: 0xff,
+		}
+		if depth == 16 && alphaBits == 1 && v&0x8000 == 0 {
+			c.A = 0
+		}
+		return c
+	case 24:
+		return color.NRGBA{R: p[2], G: p[1], B: p[0], A: 0xff}
+	}
+	return color.NRGBA{R: p[2], G: p[1], B: p[0], A: p[3]}
+}
+
+func decodeTGA(r io.Reader) (image.Image, error) {
+	h, err := readTGAHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(h.idLength)); err != nil {
+		return nil, fmt.Errorf("tga: truncated image")
+	}
+
+	// A color map may be present even in images that don't use it, it is
+	// skipped then
+	var palette []color.NRGBA
+	if h.colorMapType == 1 {
+		entrySize := (h.mapDepth + 7) / 8
+		raw := make([]byte, h.mapLength*entrySize)
+		if err := readFull("tga", r, raw); err != nil {
+			return nil, err
+		}
+		if h.imageType&^tgaRLE == tgaColorMapped {
+			palette = make([]color.NRGBA, h.mapLength)
+			for i := range palette {
+				palette[i] = tgaColor(raw[i*entrySize:], h.mapDepth, h.alphaBits())
+			}
+		}
+	}
+
+	// Read the pixels in file order, unpacking run-length packets, which may
+	// run across rows
+	pixelSize := (h.depth + 7) / 8
+	count := h.width * h.height
+	var data []byte
+	if h.imageType&tgaRLE == 0 {
+		if data, err = readData("tga", r, count*pixelSize); err != nil {
+			return nil, err
+		}
+	} else {
+		// Grown packet by packet like readData, not sized from the header
+		var packet [1]byte
+		for len(data) < count*pixelSize {
+			if err := readFull("tga", r, packet[:]); err != nil {
+				return nil, err
+			}
+			n := int(packet[0]&0x7f) + 1
+			if len(data)+n*pixelSize > count*pixelSize {
+				return nil, fmt.Errorf("tga: run-length packet overflows the image")
+			}
+			i := len(data)
+			data = append(data, make([]byte, n*pixelSize)...)
+			if packet[0]&0x80 == 0 {
+				if err := readFull("tga", r, data[i:]); err != nil {
+					return nil, err
+				}
+			} else {
+				if err := readFull("tga", r, data[i:i+pixelSize]); err != nil {
+					return nil, err
+				}
+				for k := 1; k < n; k++ {
+					copy(data[i+k*pixelSize:], data[i:i+pixelSize])
+				}
+			}
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+	visible := false
+	for i := 0; i < count; i++ {
+		p := data[i*pixelSize:]
+		var c color.NRGBA
+		switch h.imageType &^ tgaRLE {
+		case tgaColorMapped:
+			index := int(p[0])
+			if pixelSize == 2 {
+				index |= int(p[1]) << 8
+			}
+			index -= h.mapFirst
+			if index >= 0 && index < len(palette) {
+				c = palette[index]
+			}
+		case tgaGrayscale:
+			c = color.NRGBA{R: p[0], G: p[0], B: p[0], A: 0xff}
+			if pixelSize == 2 {
+				c.A = p[1]
+			}
+		default:
+			c = tgaColor(p, h.depth, h.alphaBits())
+		}
+		if c.A != 0 {
+			visible = true
+		}
+
+		// Rows are stored bottom to top unless the descriptor says otherwise
+		x, y := i%h.width, i/h.width
+		if h.descriptor&tgaRightToLeft != 0 {
+			x = h.width - 1 - x
+		}
+		if h.descriptor&tgaTopToBottom == 0 {
+			y = h.height - 1 - y
+		}
+		o := img.PixOffset(x, y)
+		img.Pix[o+0], img.Pix[o+1], img.Pix[o+2], img.Pix[o+3] = c.R, c.G, c.B, c.A
+	}
+
+	// Plenty of writers store 32 bit pixels with the alpha byte left at zero;
+	// an image with nothing visible in it is that, not a blank image
+	if !visible {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,202 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// tgaSpec describes a TGA file for tgaFile to build.
+type tgaSpec struct {
+	imageType     int
+	depth         int
+	descriptor    byte
+	width, height int
+	id            string
+	mapFirst      int
+	mapDepth      int    // 0 for no color map
+	colorMap      []byte // the stored entries
+	data          []byte
+}
+
+func tgaFile(s tgaSpec) []byte {
+	var header [18]byte
+	header[0] = byte(len(s.id))
+	header[2] = byte(s.imageType)
+	if s.mapDepth > 0 {
+		header[1] = 1
+		binary.LittleEndian.PutUint16(header[3:], uint16(s.mapFirst))
+		binary.LittleEndian.PutUint16(header[5:], uint16(len(s.colorMap)/((s.mapDepth+7)/8)))
+		header[7] = byte(s.mapDepth)
+	}
+	binary.LittleEndian.PutUint16(header[12:], uint16(s.width))
+	binary.LittleEndian.PutUint16(header[14:], uint16(s.height))
+	header[16] = byte(s.depth)
+	header[17] = s.descriptor
+
+	data := append(header[:], s.id...)
+	data = append(data, s.colorMap...)
+	return append(data, s.data...)
+}
+
+func bgr(c color.NRGBA) []byte  { return []byte{c.B, c.G, c.R} }
+func bgra(c color.NRGBA) []byte { return []byte{c.B, c.G, c.R, c.A} }
+
+// tgaPixels stores rows in the order a descriptor asks for.
+func tgaPixels(rows [][]color.NRGBA, bottomUp, rightToLeft bool, encode func(color.NRGBA) []byte) []byte {
+	var data []byte
+	for i := range rows {
+		row := rows[i]
+		if bottomUp {
+			row = rows[len(rows)-1-i]
+		}
+		for j := range row {
+			c := row[j]
+			if rightToLeft {
+				c = row[len(row)-1-j]
+			}
+			data = append(data, encode(c)...)
+		}
+	}
+	return data
+}
+
+func decodeTGABytes(data []byte) error {
+	_, err := decodeTGA(bytes.NewReader(data))
+	return err
+}
+
+func TestTGA(t *testing.T) {
+	translucent := [][]color.NRGBA{
+		{red, {0, 255, 0, 128}, blue},
+		{white, black, {}},
+	}
+	var unusedAlpha []byte
+	for _, row := range ref {
+		for _, c := range row {
+			c.A = 0
+			unusedAlpha = append(unusedAlpha, bgra(c)...)
+		}
+	}
+	var grayAlpha []byte
+	for _, v := range []byte{0, 51, 102, 153, 204, 255} {
+		grayAlpha = append(grayAlpha, v, 255)
+	}
+	le := func(v ...uint16) []byte {
+		var data []byte
+		for _, x := range v {
+			data = binary.LittleEndian.AppendUint16(data, x)
+		}
+		return data
+	}
+	var colorMap []byte
+	for _, row := range ref {
+		for _, c := range row {
+			colorMap = append(colorMap, bgr(c)...)
+		}
+	}
+
+	decodeCases(t, []codecCase{
+		{"bottom-up", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 24, width: 3, height: 2,
+			data: tgaPixels(ref, true, false, bgr),
+		}), "tga", ref},
+		{"top-down with alpha and an image id", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 32, descriptor: tgaTopToBottom | 8, width: 3, height: 2, id: "hello",
+			data: tgaPixels(translucent, false, false, bgra),
+		}), "tga", translucent},
+		{"top-down right to left", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 24, descriptor: tgaTopToBottom | tgaRightToLeft, width: 3, height: 2,
+			data: tgaPixels(ref, false, true, bgr),
+		}), "tga", ref},
+		{"bottom-up right to left", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 24, descriptor: tgaRightToLeft, width: 3, height: 2,
+			data: tgaPixels(ref, true, true, bgr),
+		}), "tga", ref},
+		{"alpha byte left at zero", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 32, descriptor: tgaTopToBottom | 8, width: 3, height: 2,
+			data: unusedAlpha,
+		}), "tga", ref},
+		{"16-bit with an alpha bit", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 16, descriptor: tgaTopToBottom | 1, width: 3, height: 2,
+			data: le(0xfc00, 0x83e0, 0x801f, 0xffff, 0x8000, 0x4210),
+		}), "tga", [][]color.NRGBA{{red, green, blue}, {white, black, {}}}},
+		{"15-bit", tgaFile(tgaSpec{
+			imageType: tgaTrueColor, depth: 15, descriptor: tgaTopToBottom, width: 3, height: 2,
+			data: le(0x7c00, 0x03e0, 0x001f, 0x7fff, 0x0000, 0x4210),
+		}), "tga", [][]color.NRGBA{{red, green, blue}, {white, black, gray5}}},
+		{"grayscale", tgaFile(tgaSpec{
+			imageType: tgaGrayscale, depth: 8, descriptor: tgaTopToBottom, width: 3, height: 2,
+			data: []byte{0, 51, 102, 153, 204, 255},
+		}), "tga", grays},
+		{"grayscale with alpha", tgaFile(tgaSpec{
+			imageType: tgaGrayscale, depth: 16, descriptor: tgaTopToBottom | 8, width: 3, height: 2,
+			data: grayAlpha,
+		}), "tga", grays},
+		{"color mapped from index 2", tgaFile(tgaSpec{
+			imageType: tgaColorMapped, depth: 8, descriptor: tgaTopToBottom, width: 3, height: 2,
+			mapFirst: 2, mapDepth: 24, colorMap: colorMap,
+			data: []byte{2, 3, 4, 5, 6, 7},
+		}), "tga", ref},
+		{"run-length across rows", tgaFile(tgaSpec{
+			imageType: tgaTrueColor | tgaRLE, depth: 24, descriptor: tgaTopToBottom, width: 3, height: 2,
+			data: append(append([]byte{0x83}, bgr(red)...), append(append([]byte{0x01}, bgr(blue)...), bgr(blue)...)...),
+		}), "tga", [][]color.NRGBA{{red, red, red}, {red, blue, blue}}},
+		{"run-length color mapped", tgaFile(tgaSpec{
+			imageType: tgaColorMapped | tgaRLE, depth: 8, width: 3, height: 2,
+			mapDepth: 24, colorMap: append(bgr(red), bgr(blue)...),
+			data: []byte{0x82, 1, 0x02, 0, 1, 0},
+		}), "tga", [][]color.NRGBA{{red, blue, red}, {blue, blue, blue}}},
+		{"run-length grayscale", tgaFile(tgaSpec{
+			imageType: tgaGrayscale | tgaRLE, depth: 8, width: 3, height: 2,
+			data: []byte{0x85, 128},
+		}), "tga", [][]color.NRGBA{{gray, gray, gray}, {gray, gray, gray}}},
+	})
+}
+
+func TestTGAErrors(t *testing.T) {
+	truecolor := tgaSpec{imageType: tgaTrueColor, depth: 24, width: 3, height: 2, data: tgaPixels(ref, true, false, bgr)}
+	mapped := tgaSpec{
+		imageType: tgaColorMapped, depth: 8, width: 2, height: 1, id: "x",
+		mapDepth: 32, colorMap: append(bgra(red), bgra(blue)...), data: []byte{1, 0},
+	}
+	rle := tgaSpec{
+		imageType: tgaTrueColor | tgaRLE, depth: 24, width: 3, height: 2,
+		data: append(append([]byte{0x84}, bgr(red)...), 0x00, 1, 2, 3),
+	}
+	with := func(s tgaSpec, change func(*tgaSpec)) []byte {
+		change(&s)
+		return tgaFile(s)
+	}
+
+	decodeErrors(t, decodeTGABytes, []errorCase{
+		{"short pixel data", with(truecolor, func(s *tgaSpec) { s.data = s.data[:10] }), "truncated"},
+		{"short image id", tgaFile(mapped)[:18], "truncated"},
+		{"short color map", tgaFile(mapped)[:24], "truncated"},
+		{"short run-length data", with(rle, func(s *tgaSpec) { s.data = s.data[:6] }), "truncated"},
+		{"run-length overflow", with(rle, func(s *tgaSpec) { s.data = append([]byte{0x86}, s.data[1:]...) }), "overflows"},
+		{"8-bit truecolor", with(truecolor, func(s *tgaSpec) { s.depth = 8 }), "unsupported"},
+		{"color mapped without a map", with(mapped, func(s *tgaSpec) { s.mapDepth = 0 }), "unsupported"},
+		{"12-bit map entries", with(mapped, func(s *tgaSpec) { s.mapDepth = 12 }), "unsupported"},
+		{"unknown image type", with(truecolor, func(s *tgaSpec) { s.imageType = 4 }), "unsupported"},
+		{"zero width", with(truecolor, func(s *tgaSpec) { s.width = 0 }), "invalid dimensions"},
+		{"oversized header", with(truecolor, func(s *tgaSpec) { s.width, s.height = 65535, 65535 }), "too large"},
+	})
+
+	truncations(t, decodeTGABytes, tgaFile(truecolor), 0)
+	truncations(t, decodeTGABytes, tgaFile(mapped), 0)
+	truncations(t, decodeTGABytes, tgaFile(rle), 0)
+}
+
+func TestTGAShortFileAllocation(t *testing.T) {
+	for _, s := range []tgaSpec{
+		{imageType: tgaTrueColor, depth: 32, width: 8192, height: 8192, data: []byte{1, 2, 3, 4}},
+		{imageType: tgaTrueColor | tgaRLE, depth: 32, width: 8192, height: 8192, data: []byte{0xff, 1, 2, 3, 4}},
+	} {
+		data := tgaFile(s)
+		if n := allocated(func() { decodeTGABytes(data) }); n > shortFileLimit {
+			t.Errorf("image type %d with no data allocated %d bytes", s.imageType, n)
+		}
+	}
+}
@@ -63,7 +63,7 @@ for i in "${!architectures[@]}"; do
     print_status "Building for Windows $arch_name ($arch)..."
     
     # Build the executable
-    env GOOS=windows GOARCH=$arch go build -o "$output_file"
+    env GOOS=windows GOARCH=$arch go build -o "$output_file" ./cmd/brainrot-ascii
     
     # Check if the build was successful
     if [ $? -eq 0 ] && [ -f "$output_file" ]; then